@@ -0,0 +1,26 @@
+package resource
+
+import (
+	"fmt"
+	"os"
+)
+
+// BuildURL links to the Concourse build running the resource, or returns an
+// empty string when the build metadata is not available.
+func BuildURL() string {
+	externalURL := os.Getenv("ATC_EXTERNAL_URL")
+	team := os.Getenv("BUILD_TEAM_NAME")
+	pipeline := os.Getenv("BUILD_PIPELINE_NAME")
+	job := os.Getenv("BUILD_JOB_NAME")
+	build := os.Getenv("BUILD_NAME")
+
+	if externalURL == "" || pipeline == "" || job == "" || build == "" {
+		return ""
+	}
+
+	if team == "" {
+		team = "main"
+	}
+
+	return fmt.Sprintf("%s/teams/%s/pipelines/%s/jobs/%s/builds/%s", externalURL, team, pipeline, job, build)
+}
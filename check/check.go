@@ -0,0 +1,17 @@
+package check
+
+import (
+	"context"
+
+	"github.com/cjcjameson/tracker-story-resource"
+)
+
+// Run lists the versions after the request's. Versions only come from puts,
+// so there are never any new ones to find.
+func Run(ctx context.Context, request CheckRequest, env resource.Env) ([]resource.Version, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	return []resource.Version{}, nil
+}
@@ -1,7 +1,32 @@
 package main
 
-import "fmt"
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/cjcjameson/tracker-story-resource"
+	"github.com/cjcjameson/tracker-story-resource/check"
+)
 
 func main() {
-	fmt.Println("[]")
+	var request check.CheckRequest
+	if err := json.NewDecoder(os.Stdin).Decode(&request); err != nil {
+		fatal(fmt.Errorf("reading request from stdin: %s", err))
+	}
+
+	versions, err := check.Run(context.Background(), request, resource.Env{Log: os.Stderr})
+	if err != nil {
+		fatal(err)
+	}
+
+	if err := json.NewEncoder(os.Stdout).Encode(versions); err != nil {
+		fatal(fmt.Errorf("writing response: %s", err))
+	}
+}
+
+func fatal(err error) {
+	fmt.Fprintf(os.Stderr, "error %s\n", err)
+	os.Exit(1)
 }
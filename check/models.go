@@ -0,0 +1,8 @@
+package check
+
+import "github.com/cjcjameson/tracker-story-resource"
+
+type CheckRequest struct {
+	Source  resource.Source   `json:"source"`
+	Version *resource.Version `json:"version"`
+}
@@ -0,0 +1,416 @@
+// Package cli runs the resource's operations from a terminal, so what a
+// pipeline would do can be reproduced without crafting requests by hand.
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/XenoPhex/go-tracker"
+	"github.com/cjcjameson/tracker-story-resource"
+	"github.com/cjcjameson/tracker-story-resource/check"
+	"github.com/cjcjameson/tracker-story-resource/in"
+	"github.com/cjcjameson/tracker-story-resource/out"
+)
+
+const usage = `usage: tracker-story <command> [flags] [arguments]
+
+commands:
+  create   create stories as a put would, from params, a manifest or a report
+  plan     show what create would change, without changing anything
+  deliver  deliver finished stories, by ID or referenced in commits
+  comment  comment on a story
+  list     list stories
+  export   export stories with their tasks and comments as JSON, CSV or Markdown
+  check    run the resource's check
+
+Every command takes --config, --token, --project and --url. They default to
+TRACKER_CONFIG, TRACKER_TOKEN, TRACKER_PROJECT and TRACKER_URL.
+`
+
+type CLI struct {
+	Stdout io.Writer
+	Stderr io.Writer
+
+	// Getenv looks up environment variables. It defaults to os.Getenv.
+	Getenv func(string) string
+
+	// Env is passed to the resource's operations, e.g. to inject a client.
+	Env resource.Env
+}
+
+type command func(ctx context.Context, c CLI, source resource.Source, flags *flag.FlagSet) error
+
+type commandFlags func(flags *flag.FlagSet) command
+
+var commands = map[string]commandFlags{
+	"create":  createFlags,
+	"plan":    planFlags,
+	"deliver": deliverFlags,
+	"comment": commentFlags,
+	"list":    listFlags,
+	"export":  exportFlags,
+	"check":   checkFlags,
+}
+
+func (c CLI) Run(ctx context.Context, args []string) error {
+	if len(args) == 0 || args[0] == "help" || args[0] == "-h" || args[0] == "--help" {
+		fmt.Fprint(c.stdout(), usage)
+		return nil
+	}
+
+	newCommand, found := commands[args[0]]
+	if !found {
+		fmt.Fprint(c.stderr(), usage)
+		return fmt.Errorf("unknown command: %s", args[0])
+	}
+
+	flags := flag.NewFlagSet(args[0], flag.ContinueOnError)
+	flags.SetOutput(c.stderr())
+
+	var sourceFlags sourceFlags
+	flags.StringVar(&sourceFlags.config, "config", "", "YAML file with the resource's source")
+	flags.StringVar(&sourceFlags.token, "token", "", "Tracker API token")
+	flags.StringVar(&sourceFlags.projectID, "project", "", "Tracker project ID")
+	flags.StringVar(&sourceFlags.url, "url", "", "Tracker URL")
+
+	run := newCommand(flags)
+	if err := flags.Parse(args[1:]); err != nil {
+		return err
+	}
+
+	source, err := c.source(sourceFlags)
+	if err != nil {
+		return err
+	}
+
+	return run(ctx, c, source, flags)
+}
+
+func (c CLI) stdout() io.Writer {
+	if c.Stdout == nil {
+		return ioutil.Discard
+	}
+
+	return c.Stdout
+}
+
+func (c CLI) stderr() io.Writer {
+	if c.Stderr == nil {
+		return ioutil.Discard
+	}
+
+	return c.Stderr
+}
+
+func (c CLI) getenv(name string) string {
+	if c.Getenv == nil {
+		return os.Getenv(name)
+	}
+
+	return c.Getenv(name)
+}
+
+// env logs the resource's progress to stdout, where a terminal user looks.
+func (c CLI) env() resource.Env {
+	env := c.Env
+	env.Log = c.stdout()
+	return env
+}
+
+func createFlags(flags *flag.FlagSet) command {
+	return putFlags(flags, false)
+}
+
+func planFlags(flags *flag.FlagSet) command {
+	return putFlags(flags, true)
+}
+
+// putFlags runs a put, or with dryRun plans one, writing the plan to --out.
+func putFlags(flags *flag.FlagSet, dryRun bool) command {
+	var planFile *string
+	if dryRun {
+		planFile = flags.String("out", "plan.json", "file to write the plan to")
+	}
+
+	sources := flags.String("sources", ".", "directory that paths in params are relative to")
+	paramsPath := flags.String("params", "", "YAML file with the put's params")
+	manifest := flags.String("manifest", "", "YAML manifest of stories to create")
+	content := flags.String("content", "", "file to create a story or file findings from")
+	format := flags.String("format", "", "format of the content file, e.g. junit")
+
+	return func(ctx context.Context, c CLI, source resource.Source, flags *flag.FlagSet) error {
+		var params out.Params
+		if *paramsPath != "" {
+			if err := readYAML(*paramsPath, &params); err != nil {
+				return fmt.Errorf("reading params: %s", err)
+			}
+		}
+
+		if *manifest != "" {
+			params.ManifestPath = *manifest
+		}
+
+		if *content != "" {
+			params.ContentPath = *content
+		}
+
+		if *format != "" {
+			params.Format = *format
+		}
+
+		if dryRun {
+			path, err := filepath.Abs(*planFile)
+			if err != nil {
+				return err
+			}
+
+			params.DryRun = true
+			params.PlanFile = path
+		}
+
+		_, err := out.Run(ctx, out.OutRequest{Source: source, Params: params}, *sources, c.env())
+		return err
+	}
+}
+
+func deliverFlags(flags *flag.FlagSet) command {
+	comment := flags.String("comment", "", "comment to leave on delivered stories")
+	repo := flags.String("repo", "", "git repository whose commits reference stories")
+	from := flags.String("from", "", "deliver stories referenced after this revision")
+	to := flags.String("to", "HEAD", "deliver stories referenced up to this revision")
+	dryRun := flags.Bool("dry-run", false, "print what would change without changing it")
+
+	return func(ctx context.Context, c CLI, source resource.Source, flags *flag.FlagSet) error {
+		ids, err := storyIDs(flags.Args())
+		if err != nil {
+			return err
+		}
+
+		if *repo != "" {
+			messages, err := resource.CommitMessages(*repo, *from, *to)
+			if err != nil {
+				return fmt.Errorf("reading commits: %s", err)
+			}
+
+			ids = append(ids, resource.StoryIDs(messages)...)
+		}
+
+		if len(ids) == 0 {
+			return errors.New("no stories to deliver: give story IDs or --repo")
+		}
+
+		client, err := c.Env.ProjectClient(ctx, source)
+		if err != nil {
+			return err
+		}
+
+		writer, done := c.writer(client, *dryRun)
+		defer done()
+
+		for _, id := range ids {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+
+			story, err := client.Story(id)
+			if err != nil {
+				return fmt.Errorf("fetching story %d: %s", id, err)
+			}
+
+			if story.State != tracker.StoryStateFinished {
+				fmt.Fprintf(c.stdout(), "Story %d is %s, not finished\n", id, story.State)
+				continue
+			}
+
+			_, err = writer.UpdateStory(tracker.Story{ID: id, State: tracker.StoryStateDelivered})
+			if err != nil {
+				return fmt.Errorf("delivering story %d: %s", id, err)
+			}
+
+			if *comment != "" {
+				_, err = writer.CreateComment(id, tracker.Comment{Text: *comment})
+				if err != nil {
+					return fmt.Errorf("commenting on story %d: %s", id, err)
+				}
+			}
+
+			if !*dryRun {
+				fmt.Fprintf(c.stdout(), "Story %d delivered\n", id)
+			}
+		}
+
+		return nil
+	}
+}
+
+func commentFlags(flags *flag.FlagSet) command {
+	dryRun := flags.Bool("dry-run", false, "print what would change without changing it")
+
+	return func(ctx context.Context, c CLI, source resource.Source, flags *flag.FlagSet) error {
+		if flags.NArg() < 2 {
+			return errors.New("usage: tracker-story comment [flags] <story ID> <text>")
+		}
+
+		ids, err := storyIDs(flags.Args()[:1])
+		if err != nil {
+			return err
+		}
+
+		client, err := c.Env.ProjectClient(ctx, source)
+		if err != nil {
+			return err
+		}
+
+		writer, done := c.writer(client, *dryRun)
+		defer done()
+
+		_, err = writer.CreateComment(ids[0], tracker.Comment{
+			Text: strings.Join(flags.Args()[1:], " "),
+		})
+		if err != nil {
+			return fmt.Errorf("commenting on story %d: %s", ids[0], err)
+		}
+
+		if !*dryRun {
+			fmt.Fprintf(c.stdout(), "Comment added to story %d\n", ids[0])
+		}
+
+		return nil
+	}
+}
+
+// queryFlags are the story filters shared by list and export.
+func queryFlags(flags *flag.FlagSet) *tracker.StoriesQuery {
+	query := &tracker.StoriesQuery{}
+	flags.Var((*stateFlag)(&query.State), "state", "only stories in this state")
+	flags.StringVar(&query.Label, "label", "", "only stories with this label")
+	flags.StringVar(&query.Filter, "filter", "", "Tracker search filter, e.g. -state:accepted")
+	return query
+}
+
+func listFlags(flags *flag.FlagSet) command {
+	query := queryFlags(flags)
+
+	return func(ctx context.Context, c CLI, source resource.Source, flags *flag.FlagSet) error {
+		stories, err := c.stories(ctx, source, *query)
+		if err != nil {
+			return err
+		}
+
+		for _, story := range stories {
+			fmt.Fprintf(c.stdout(), "#%d\t%s\t%s\t%s\n", story.ID, story.State, story.Type, story.Name)
+		}
+
+		return nil
+	}
+}
+
+func exportFlags(flags *flag.FlagSet) command {
+	query := queryFlags(flags)
+	format := flags.String("format", "json", "json, csv or markdown")
+
+	return func(ctx context.Context, c CLI, source resource.Source, flags *flag.FlagSet) error {
+		client, err := c.Env.ProjectClient(ctx, source)
+		if err != nil {
+			return err
+		}
+
+		export, err := in.FetchExport(client, in.ExportParams{
+			State:  query.State,
+			Label:  query.Label,
+			Filter: query.Filter,
+		})
+		if err != nil {
+			return fmt.Errorf("fetching stories: %s", err)
+		}
+
+		var contents []byte
+		switch *format {
+		case "json":
+			contents, err = export.JSON()
+		case "csv":
+			contents, err = export.CSV()
+		case "markdown", "md":
+			contents = []byte(export.Markdown())
+		default:
+			return fmt.Errorf("unknown export format: %s", *format)
+		}
+		if err != nil {
+			return err
+		}
+
+		_, err = c.stdout().Write(contents)
+		return err
+	}
+}
+
+func checkFlags(flags *flag.FlagSet) command {
+	return func(ctx context.Context, c CLI, source resource.Source, flags *flag.FlagSet) error {
+		versions, err := check.Run(ctx, check.CheckRequest{Source: source}, c.env())
+		if err != nil {
+			return err
+		}
+
+		return json.NewEncoder(c.stdout()).Encode(versions)
+	}
+}
+
+// writer makes a command's changes with the client, or with dryRun plans
+// them, printing the plan when the command is done.
+func (c CLI) writer(client resource.Client, dryRun bool) (out.Writer, func()) {
+	if !dryRun {
+		return client, func() {}
+	}
+
+	plan := out.NewPlan(client)
+	return plan, func() { fmt.Fprint(c.stdout(), plan) }
+}
+
+func (c CLI) stories(ctx context.Context, source resource.Source, query tracker.StoriesQuery) ([]tracker.Story, error) {
+	client, err := c.Env.ProjectClient(ctx, source)
+	if err != nil {
+		return nil, err
+	}
+
+	stories, err := resource.AllStories(client, query)
+	if err != nil {
+		return nil, fmt.Errorf("fetching stories: %s", err)
+	}
+
+	return stories, nil
+}
+
+func storyIDs(args []string) ([]int, error) {
+	var ids []int
+	for _, arg := range args {
+		id, err := strconv.Atoi(strings.TrimPrefix(arg, "#"))
+		if err != nil {
+			return nil, fmt.Errorf("invalid story ID: %s", arg)
+		}
+
+		ids = append(ids, id)
+	}
+
+	return ids, nil
+}
+
+type stateFlag tracker.StoryState
+
+func (s *stateFlag) String() string {
+	return string(*s)
+}
+
+func (s *stateFlag) Set(value string) error {
+	*s = stateFlag(value)
+	return nil
+}
@@ -0,0 +1,188 @@
+package cli_test
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/XenoPhex/go-tracker"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+
+	"github.com/cjcjameson/tracker-story-resource/cli"
+	"github.com/cjcjameson/tracker-story-resource/trackerfake"
+)
+
+var _ = Describe("CLI", func() {
+	var (
+		fake    *trackerfake.Server
+		command cli.CLI
+		stdout  *gbytes.Buffer
+		environ map[string]string
+		tmpdir  string
+	)
+
+	BeforeEach(func() {
+		fake = trackerfake.New("abc", 1234)
+
+		environ = map[string]string{
+			"TRACKER_TOKEN":   "abc",
+			"TRACKER_PROJECT": "1234",
+			"TRACKER_URL":     fake.URL(),
+		}
+
+		stdout = gbytes.NewBuffer()
+		command = cli.CLI{
+			Stdout: stdout,
+			Getenv: func(name string) string { return environ[name] },
+		}
+
+		var err error
+		tmpdir, err = ioutil.TempDir("", "cli")
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		fake.Close()
+		os.RemoveAll(tmpdir)
+	})
+
+	run := func(args ...string) error {
+		return command.Run(context.Background(), args)
+	}
+
+	It("creates stories from a manifest as a put would", func() {
+		err := ioutil.WriteFile(filepath.Join(tmpdir, "manifest.yml"), []byte(`
+stories:
+- name: Cover the exhaust port
+  type: bug
+`), 0644)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(run("create", "--sources", tmpdir, "--manifest", "manifest.yml")).To(Succeed())
+		Expect(stdout).To(gbytes.Say("Story created with ID: \\d+ Name: Cover the exhaust port"))
+
+		stories := fake.Stories()
+		Expect(stories).To(HaveLen(1))
+		Expect(stories[0].Type).To(BeEquivalentTo(tracker.StoryTypeBug))
+	})
+
+	It("reads put params from YAML", func() {
+		err := ioutil.WriteFile(filepath.Join(tmpdir, "manifest.yml"), []byte("stories: [{name: Vent}]"), 0644)
+		Expect(err).NotTo(HaveOccurred())
+
+		err = ioutil.WriteFile(filepath.Join(tmpdir, "params.yml"), []byte(`
+manifest: manifest.yml
+position:
+  into_current_iteration: true
+`), 0644)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(run("create", "--sources", tmpdir, "--params", filepath.Join(tmpdir, "params.yml"))).To(Succeed())
+		Expect(fake.Stories()[0].State).To(BeEquivalentTo(tracker.StoryStateUnstarted))
+	})
+
+	It("plans a put without changing the project", func() {
+		err := ioutil.WriteFile(filepath.Join(tmpdir, "manifest.yml"), []byte("stories: [{name: Vent}]"), 0644)
+		Expect(err).NotTo(HaveOccurred())
+
+		planFile := filepath.Join(tmpdir, "vent.json")
+		Expect(run("plan", "--sources", tmpdir, "--manifest", "manifest.yml", "--out", planFile)).To(Succeed())
+		Expect(stdout).To(gbytes.Say(`\+ create chore "Vent"`))
+		Expect(fake.Stories()).To(BeEmpty())
+
+		_, err = os.Stat(planFile)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("delivers finished stories only", func() {
+		finished := fake.AddStory(tracker.Story{Name: "Vent", State: tracker.StoryStateFinished})
+		started := fake.AddStory(tracker.Story{Name: "Shield", State: tracker.StoryStateStarted})
+
+		Expect(run("deliver", "--comment", "in staging", strconv.Itoa(finished.ID), "#"+strconv.Itoa(started.ID))).To(Succeed())
+		Expect(stdout).To(gbytes.Say("Story %d delivered", finished.ID))
+		Expect(stdout).To(gbytes.Say("Story %d is started, not finished", started.ID))
+
+		delivered, _ := fake.Story(finished.ID)
+		Expect(delivered.State).To(BeEquivalentTo(tracker.StoryStateDelivered))
+		Expect(fake.Comments(finished.ID)[0].Text).To(Equal("in staging"))
+	})
+
+	It("comments on a story", func() {
+		story := fake.AddStory(tracker.Story{Name: "Vent"})
+
+		Expect(run("comment", strconv.Itoa(story.ID), "looks", "good")).To(Succeed())
+		Expect(fake.Comments(story.ID)[0].Text).To(Equal("looks good"))
+	})
+
+	It("plans deliveries and comments without making them", func() {
+		finished := fake.AddStory(tracker.Story{Name: "Vent", State: tracker.StoryStateFinished})
+
+		Expect(run("deliver", "--dry-run", "--comment", "in staging", strconv.Itoa(finished.ID))).To(Succeed())
+		Expect(stdout).To(gbytes.Say(`~ update #%d "Vent"\n`, finished.ID))
+		Expect(stdout).To(gbytes.Say(`~ comment on #%d\n`, finished.ID))
+		Expect(stdout).To(gbytes.Say(`Plan: 0 to create, 1 to update, 0 to delete, 0 labels, 1 comments, 0 blockers, 0 epics.`))
+
+		Expect(run("comment", "--dry-run", strconv.Itoa(finished.ID), "looks", "good")).To(Succeed())
+		Expect(stdout).To(gbytes.Say(`    \+ looks good\n`))
+
+		story, _ := fake.Story(finished.ID)
+		Expect(story.State).To(BeEquivalentTo(tracker.StoryStateFinished))
+		Expect(fake.Comments(finished.ID)).To(BeEmpty())
+	})
+
+	It("lists and exports stories", func() {
+		fake.AddStory(tracker.Story{Name: "Vent", State: tracker.StoryStateStarted, Type: tracker.StoryTypeChore})
+		fake.AddStory(tracker.Story{Name: "Shield", State: tracker.StoryStateAccepted})
+
+		Expect(run("list", "--filter", "-state:accepted")).To(Succeed())
+		Expect(stdout).To(gbytes.Say("#\\d+\tstarted\tchore\tVent\n"))
+		Expect(stdout).NotTo(gbytes.Say("Shield"))
+
+		stdout = gbytes.NewBuffer()
+		command.Stdout = stdout
+		Expect(run("export", "--state", "accepted")).To(Succeed())
+
+		var stories []tracker.Story
+		Expect(json.Unmarshal(stdout.Contents(), &stories)).To(Succeed())
+		Expect(stories).To(HaveLen(1))
+		Expect(stories[0].Name).To(Equal("Shield"))
+
+		stdout = gbytes.NewBuffer()
+		command.Stdout = stdout
+		Expect(run("export", "--format", "csv", "--state", "started")).To(Succeed())
+		Expect(stdout).To(gbytes.Say("id,type,state,name,"))
+		Expect(stdout).To(gbytes.Say(`\d+,chore,started,Vent,`))
+
+		Expect(run("export", "--format", "xml")).To(MatchError("unknown export format: xml"))
+	})
+
+	It("runs the check", func() {
+		Expect(run("check")).To(Succeed())
+		Expect(stdout).To(gbytes.Say(`\[\]`))
+	})
+
+	It("reads the source from a config file, overridden by the environment and flags", func() {
+		config := filepath.Join(tmpdir, "tracker.yml")
+		err := ioutil.WriteFile(config, []byte("token: abc\nproject_id: \"1234\"\ntracker_url: http://127.0.0.1:1\n"), 0644)
+		Expect(err).NotTo(HaveOccurred())
+
+		environ = map[string]string{"TRACKER_CONFIG": config, "TRACKER_URL": fake.URL(), "TRACKER_PROJECT": "999"}
+
+		Expect(run("list", "--project", "1234")).To(Succeed())
+		Expect(fake.Requests()).To(HaveLen(1))
+	})
+
+	It("requires a project", func() {
+		environ = map[string]string{}
+		Expect(run("list")).To(MatchError(ContainSubstring("no project")))
+	})
+
+	It("rejects unknown commands", func() {
+		Expect(run("launch")).To(MatchError("unknown command: launch"))
+	})
+})
@@ -0,0 +1,21 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/cjcjameson/tracker-story-resource/cli"
+)
+
+func main() {
+	command := cli.CLI{
+		Stdout: os.Stdout,
+		Stderr: os.Stderr,
+	}
+
+	if err := command.Run(context.Background(), os.Args[1:]); err != nil {
+		fmt.Fprintf(os.Stderr, "error %s\n", err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,100 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/cjcjameson/tracker-story-resource"
+	"gopkg.in/yaml.v2"
+)
+
+// sourceFlags are where a command finds its Tracker project. Flags win over
+// the environment, which wins over the config file.
+type sourceFlags struct {
+	config    string
+	token     string
+	projectID string
+	url       string
+}
+
+func (c CLI) source(flags sourceFlags) (resource.Source, error) {
+	var source resource.Source
+
+	config := flags.config
+	if config == "" {
+		config = c.getenv("TRACKER_CONFIG")
+	}
+
+	if config != "" {
+		if err := readYAML(config, &source); err != nil {
+			return source, fmt.Errorf("reading config: %s", err)
+		}
+	}
+
+	for _, setting := range []struct {
+		value *string
+		env   string
+		flag  string
+	}{
+		{&source.Token, "TRACKER_TOKEN", flags.token},
+		{&source.ProjectID, "TRACKER_PROJECT", flags.projectID},
+		{&source.TrackerURL, "TRACKER_URL", flags.url},
+	} {
+		if value := c.getenv(setting.env); value != "" {
+			*setting.value = value
+		}
+
+		if setting.flag != "" {
+			*setting.value = setting.flag
+		}
+	}
+
+	if c.Env.Client == nil && source.ProjectID == "" {
+		return source, fmt.Errorf("no project: use --project, TRACKER_PROJECT or a config file")
+	}
+
+	return source, nil
+}
+
+// readYAML reads a YAML file into a value with JSON tags, such as the
+// resource's source and params, so files look like pipeline configuration.
+func readYAML(path string, value interface{}) error {
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	var document interface{}
+	if err := yaml.Unmarshal(contents, &document); err != nil {
+		return err
+	}
+
+	converted, err := json.Marshal(jsonValue(document))
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(converted, value)
+}
+
+// jsonValue turns the maps decoded by yaml.v2 into ones encoding/json
+// accepts.
+func jsonValue(value interface{}) interface{} {
+	switch value := value.(type) {
+	case map[interface{}]interface{}:
+		converted := map[string]interface{}{}
+		for key, item := range value {
+			converted[fmt.Sprintf("%v", key)] = jsonValue(item)
+		}
+		return converted
+	case []interface{}:
+		converted := make([]interface{}, len(value))
+		for i, item := range value {
+			converted[i] = jsonValue(item)
+		}
+		return converted
+	}
+
+	return value
+}
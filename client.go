@@ -0,0 +1,123 @@
+package resource
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/XenoPhex/go-tracker"
+)
+
+const defaultTrackerURL = "https://www.pivotaltracker.com"
+
+// Reader is the part of a Tracker project that check, in and out read.
+type Reader interface {
+	Stories(query tracker.StoriesQuery) ([]tracker.Story, tracker.Pagination, error)
+	Story(storyID int) (tracker.Story, error)
+	StoryActivity(storyID int, query tracker.ActivityQuery) ([]tracker.Activity, error)
+	StoryBlockers(storyID int) ([]tracker.Blocker, error)
+	StoryTasks(storyID int) ([]tracker.Task, error)
+	Memberships() ([]tracker.Membership, error)
+	Epics() ([]tracker.Epic, error)
+	Project() (tracker.Project, error)
+}
+
+// Writer makes the changes a put decides on. The project client makes them in
+// Tracker; a plan only records them.
+type Writer interface {
+	CreateStory(story tracker.Story) (tracker.Story, error)
+	UpdateStory(story tracker.Story) (tracker.Story, error)
+	DeleteStory(storyID int) error
+	AddStoryLabel(storyID int, label tracker.Label) (tracker.Label, error)
+	CreateComment(storyID int, comment tracker.Comment) (tracker.Comment, error)
+	CreateBlocker(storyID int, blocker tracker.Blocker) (tracker.Blocker, error)
+	CreateTask(storyID int, task tracker.Task) (tracker.Task, error)
+	DeleteTask(storyID int, taskID int) error
+	CreateEpic(epic tracker.Epic) (tracker.Epic, error)
+}
+
+// Client reads and writes a Tracker project. tracker.ProjectClient is one;
+// tests can inject another.
+type Client interface {
+	Reader
+	Writer
+}
+
+// TrackerURL is the Tracker the source talks to.
+func TrackerURL(source Source) string {
+	if source.TrackerURL == "" {
+		return defaultTrackerURL
+	}
+
+	return strings.TrimSuffix(source.TrackerURL, "/")
+}
+
+func NewProjectClient(source Source) (tracker.ProjectClient, error) {
+	projectID, err := strconv.Atoi(source.ProjectID)
+	if err != nil {
+		return tracker.ProjectClient{}, err
+	}
+
+	return tracker.NewClientWithURL(source.Token, TrackerURL(source)).InProject(projectID), nil
+}
+
+// AllStories follows Tracker's pagination until every story matching the
+// query has been fetched.
+func AllStories(client Reader, query tracker.StoriesQuery) ([]tracker.Story, error) {
+	if query.Limit == 0 {
+		query.Limit = 100
+	}
+
+	var all []tracker.Story
+	for {
+		stories, pagination, err := client.Stories(query)
+		if err != nil {
+			return nil, err
+		}
+
+		all = append(all, stories...)
+
+		if len(stories) == 0 || query.Offset+len(stories) >= pagination.Total {
+			return all, nil
+		}
+
+		query.Offset += len(stories)
+	}
+}
+
+func PeopleByID(client Reader) (map[int]tracker.Person, error) {
+	memberships, err := client.Memberships()
+	if err != nil {
+		return nil, err
+	}
+
+	people := map[int]tracker.Person{}
+	for _, membership := range memberships {
+		people[membership.Person.ID] = membership.Person
+	}
+
+	return people, nil
+}
+
+func OwnerNames(story tracker.Story, people map[int]tracker.Person) []string {
+	var names []string
+	for _, id := range story.OwnerIDs {
+		if person, found := people[id]; found {
+			names = append(names, person.Name)
+		} else {
+			names = append(names, fmt.Sprintf("#%d", id))
+		}
+	}
+
+	return names
+}
+
+func HasLabel(story tracker.Story, name string) bool {
+	for _, label := range story.Labels {
+		if label.Name == name {
+			return true
+		}
+	}
+
+	return false
+}
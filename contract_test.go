@@ -0,0 +1,104 @@
+package resource_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+
+	"github.com/XenoPhex/go-tracker"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/cjcjameson/tracker-story-resource"
+)
+
+// The contract with the real Tracker API is checked against the cassette in
+// fixtures/cassettes. Record it with TRACKER_RECORD=true, TRACKER_TOKEN and
+// TRACKER_PROJECT set. Until a recording is committed, the synthetic cassette,
+// written by hand from Tracker's API reference, stands in for it.
+var _ = Describe("Contract with the real Tracker API", func() {
+	var (
+		client   tracker.ProjectClient
+		recorder *tracker.Recorder
+	)
+
+	BeforeEach(func() {
+		cassette := filepath.Join("fixtures", "cassettes", "tracker-api.json")
+		synthetic := filepath.Join("fixtures", "cassettes", "tracker-api.synthetic.json")
+
+		source := resource.Source{
+			Token:     os.Getenv("TRACKER_TOKEN"),
+			ProjectID: os.Getenv("TRACKER_PROJECT"),
+		}
+
+		var err error
+		if os.Getenv("TRACKER_RECORD") == "true" {
+			if source.Token == "" || source.ProjectID == "" {
+				Fail("TRACKER_TOKEN and TRACKER_PROJECT must be provided to record.")
+			}
+
+			recorder, err = tracker.NewRecorder(cassette, tracker.ModeRecord)
+		} else {
+			if _, err := os.Stat(cassette); os.IsNotExist(err) {
+				cassette = synthetic
+			}
+
+			// a missing cassette fails the suite rather than skipping the
+			// only check against the real API's responses
+			recorder, err = tracker.NewRecorder(cassette, tracker.ModeReplay)
+			Expect(err).NotTo(HaveOccurred())
+
+			source = resource.Source{Token: "replayed", ProjectID: recordedProjectID(cassette)}
+		}
+		Expect(err).NotTo(HaveOccurred())
+
+		tracker.DefaultTransport = recorder
+
+		client, err = resource.NewProjectClient(source)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		tracker.DefaultTransport = nil
+	})
+
+	It("creates, delivers, comments on and deletes a story", func() {
+		story, err := client.CreateStory(tracker.Story{
+			Name:  "concourse test story",
+			Type:  tracker.StoryTypeBug,
+			State: tracker.StoryStateFinished,
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(story.ID).NotTo(BeZero())
+
+		err = client.DeliverStoryWithComment(story.ID, "delivered by the contract test")
+		Expect(err).NotTo(HaveOccurred())
+
+		delivered, err := client.Story(story.ID)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(delivered.State).To(BeEquivalentTo(tracker.StoryStateDelivered))
+
+		err = client.DeleteStory(story.ID)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(recorder.Unplayed()).To(BeEmpty())
+	})
+})
+
+// recordedProjectID finds the project the cassette was recorded against, since
+// its ID is part of every recorded URL.
+func recordedProjectID(path string) string {
+	contents, err := ioutil.ReadFile(path)
+	Expect(err).NotTo(HaveOccurred())
+
+	var cassette tracker.Cassette
+	Expect(json.Unmarshal(contents, &cassette)).To(Succeed())
+	Expect(cassette.Interactions).NotTo(BeEmpty())
+
+	match := regexp.MustCompile(`^/services/v5/projects/(\d+)`).FindStringSubmatch(cassette.Interactions[0].Request.URL)
+	Expect(match).NotTo(BeNil())
+
+	return match[1]
+}
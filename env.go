@@ -0,0 +1,65 @@
+package resource
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+)
+
+// Env is what check, in and out run with. The zero value talks to the
+// Tracker named in the request's source, discards the log and uses the
+// system clock.
+type Env struct {
+	Client Client
+	Log    io.Writer
+	Now    func() time.Time
+
+	// Origin returns the client for a project other than the request's,
+	// such as the one a mirror copies from.
+	Origin func(source Source) (Client, error)
+}
+
+// ProjectClient is the injected client, or one for the source's project whose
+// requests are cancelled when ctx is.
+func (e Env) ProjectClient(ctx context.Context, source Source) (Client, error) {
+	if e.Client != nil {
+		return e.Client, nil
+	}
+
+	client, err := NewProjectClient(source)
+	if err != nil {
+		return nil, fmt.Errorf("converting the project ID to an integer: %s", err)
+	}
+
+	return client.WithContext(ctx), nil
+}
+
+// OriginClient is the injected client for another project, or one for the
+// source's project whose requests are cancelled when ctx is.
+func (e Env) OriginClient(ctx context.Context, source Source) (Client, error) {
+	if e.Origin != nil {
+		return e.Origin(source)
+	}
+
+	client, err := NewProjectClient(source)
+	if err != nil {
+		return nil, fmt.Errorf("converting the project ID to an integer: %s", err)
+	}
+
+	return client.WithContext(ctx), nil
+}
+
+func (e Env) Logf(message string, args ...interface{}) {
+	if e.Log != nil {
+		fmt.Fprintf(e.Log, message, args...)
+	}
+}
+
+func (e Env) Time() time.Time {
+	if e.Now != nil {
+		return e.Now()
+	}
+
+	return time.Now()
+}
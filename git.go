@@ -0,0 +1,227 @@
+package resource
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	trackerCommandPattern = regexp.MustCompile(`\[[^\]]*\]`)
+	storyIDPattern        = regexp.MustCompile(`#(\d+)`)
+)
+
+// LatestTag returns the most recent tag reachable from ref, or an empty
+// string if there is none.
+func LatestTag(repo string, ref string) (string, error) {
+	if _, err := git(repo, "rev-parse", "--verify", "--quiet", ref); err != nil {
+		return "", fmt.Errorf("unknown revision: %s", ref)
+	}
+
+	output, err := git(repo, "describe", "--tags", "--abbrev=0", ref)
+	if err != nil {
+		return "", nil
+	}
+
+	return strings.TrimSpace(output), nil
+}
+
+// IsRemote reports whether repo names a repository to clone rather than a
+// directory, e.g. https://github.com/org/repo or git@github.com:org/repo.
+func IsRemote(repo string) bool {
+	if strings.Contains(repo, "://") {
+		return true
+	}
+
+	at := strings.Index(repo, "@")
+	colon := strings.Index(repo, ":")
+	return at > 0 && colon > at
+}
+
+// Clone clones the repository at url into dir, with its whole history and
+// tags.
+func Clone(url string, dir string) error {
+	_, err := git("", "clone", "--quiet", url, dir)
+	return err
+}
+
+// PreviousTag returns the tag before the given one, or an empty string if it
+// is the first.
+func PreviousTag(repo string, tag string) (string, error) {
+	if _, err := git(repo, "rev-parse", "--verify", "--quiet", tag+"^"); err != nil {
+		return "", nil
+	}
+
+	return LatestTag(repo, tag+"^")
+}
+
+// CommitMessages returns the messages of the commits reachable from to but
+// not from from. An empty from means the whole history of to.
+func CommitMessages(repo string, from string, to string) ([]string, error) {
+	revisions := to
+	if from != "" {
+		revisions = from + ".." + to
+	}
+
+	output, err := git(repo, "log", "--format=%B%x00", revisions)
+	if err != nil {
+		return nil, err
+	}
+
+	var messages []string
+	for _, message := range strings.Split(output, "\x00") {
+		if message = strings.TrimSpace(message); message != "" {
+			messages = append(messages, message)
+		}
+	}
+
+	return messages, nil
+}
+
+// StoryIDs finds the stories referenced in commit messages using Tracker's
+// post-commit syntax, e.g. "[Finishes #123]". Each ID is returned once.
+func StoryIDs(messages []string) []int {
+	seen := map[int]bool{}
+
+	var ids []int
+	for _, message := range messages {
+		for _, command := range trackerCommandPattern.FindAllString(message, -1) {
+			for _, match := range storyIDPattern.FindAllStringSubmatch(command, -1) {
+				id, err := strconv.Atoi(match[1])
+				if err != nil || seen[id] {
+					continue
+				}
+
+				seen[id] = true
+				ids = append(ids, id)
+			}
+		}
+	}
+
+	return ids
+}
+
+type GrepMatch struct {
+	File string
+	Line int
+	Text string
+}
+
+// Grep finds the lines of tracked text files matching any of the extended
+// regular expressions.
+func Grep(repo string, patterns []string) ([]GrepMatch, error) {
+	args := []string{"grep", "-n", "-z", "-I", "-E"}
+	for _, pattern := range patterns {
+		args = append(args, "-e", pattern)
+	}
+
+	stdout := &bytes.Buffer{}
+	stderr := &bytes.Buffer{}
+
+	cmd := exec.Command("git", args...)
+	cmd.Dir = repo
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+
+	if err := cmd.Run(); err != nil {
+		// git grep exits 1 when nothing matches
+		if stderr.Len() == 0 {
+			if _, ok := err.(*exec.ExitError); ok {
+				return nil, nil
+			}
+		}
+
+		return nil, fmt.Errorf("git grep: %s: %s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	output := stdout.String()
+
+	var matches []GrepMatch
+	for _, line := range strings.Split(strings.TrimRight(output, "\n"), "\n") {
+		fields := strings.SplitN(line, "\x00", 3)
+		if len(fields) != 3 {
+			continue
+		}
+
+		number, err := strconv.Atoi(fields[1])
+		if err != nil {
+			continue
+		}
+
+		matches = append(matches, GrepMatch{File: fields[0], Line: number, Text: fields[2]})
+	}
+
+	return matches, nil
+}
+
+// BlameAuthor returns the author of the commit that last changed a line.
+func BlameAuthor(repo string, file string, line int) (string, error) {
+	output, err := git(repo, "blame", "--porcelain", "-L", fmt.Sprintf("%d,%d", line, line), "--", file)
+	if err != nil {
+		return "", err
+	}
+
+	for _, field := range strings.Split(output, "\n") {
+		if strings.HasPrefix(field, "author ") {
+			return strings.TrimPrefix(field, "author "), nil
+		}
+	}
+
+	return "", nil
+}
+
+// CommitTime returns when the commit ref points to was made.
+func CommitTime(repo string, ref string) (time.Time, error) {
+	output, err := git(repo, "log", "-1", "--format=%cI", ref)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	return time.Parse(time.RFC3339, strings.TrimSpace(output))
+}
+
+func Head(repo string) (string, error) {
+	output, err := git(repo, "rev-parse", "HEAD")
+	return strings.TrimSpace(output), err
+}
+
+// WebURL turns the URL of the repository's origin remote into the address of
+// its web page, e.g. git@github.com:org/repo.git becomes
+// https://github.com/org/repo. It returns an empty string when there is no
+// origin.
+func WebURL(repo string) string {
+	output, err := git(repo, "remote", "get-url", "origin")
+	if err != nil {
+		return ""
+	}
+
+	url := strings.TrimSuffix(strings.TrimSpace(output), ".git")
+	switch {
+	case strings.HasPrefix(url, "https://"), strings.HasPrefix(url, "http://"):
+		return url
+	case strings.HasPrefix(url, "git@"):
+		return "https://" + strings.Replace(strings.TrimPrefix(url, "git@"), ":", "/", 1)
+	}
+
+	return ""
+}
+
+func git(repo string, args ...string) (string, error) {
+	stdout := &bytes.Buffer{}
+	stderr := &bytes.Buffer{}
+
+	cmd := exec.Command("git", args...)
+	cmd.Dir = repo
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("git %s: %s: %s", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+
+	return stdout.String(), nil
+}
@@ -0,0 +1,174 @@
+package in
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/XenoPhex/go-tracker"
+	"github.com/cjcjameson/tracker-story-resource"
+)
+
+type Changelog struct {
+	Features []ChangelogEntry `json:"features"`
+	Bugs     []ChangelogEntry `json:"bugs"`
+	Chores   []ChangelogEntry `json:"chores"`
+}
+
+type ChangelogEntry struct {
+	ID         int        `json:"id"`
+	Name       string     `json:"name"`
+	URL        string     `json:"url"`
+	Owners     []string   `json:"owners"`
+	AcceptedAt *time.Time `json:"accepted_at,omitempty"`
+}
+
+// FetchChangelog collects the stories accepted in the range the params
+// select: those carrying a label, those accepted between two times, or both.
+func FetchChangelog(client resource.Reader, params ChangelogParams, until time.Time) (Changelog, error) {
+	query := tracker.StoriesQuery{
+		State: tracker.StoryStateAccepted,
+		Label: params.Label,
+	}
+
+	// a label alone selects every story carrying it, whenever accepted
+	query.AcceptedAfter = params.From
+	query.AcceptedBefore = params.To
+	if query.AcceptedBefore.IsZero() && (params.Label == "" || !params.From.IsZero()) {
+		query.AcceptedBefore = until
+	}
+
+	stories, err := resource.AllStories(client, query)
+	if err != nil {
+		return Changelog{}, err
+	}
+
+	people, err := resource.PeopleByID(client)
+	if err != nil {
+		return Changelog{}, err
+	}
+
+	return BuildChangelog(stories, people), nil
+}
+
+func BuildChangelog(stories []tracker.Story, people map[int]tracker.Person) Changelog {
+	changelog := Changelog{
+		Features: []ChangelogEntry{},
+		Bugs:     []ChangelogEntry{},
+		Chores:   []ChangelogEntry{},
+	}
+
+	for _, story := range stories {
+		entry := ChangelogEntry{
+			ID:         story.ID,
+			Name:       story.Name,
+			URL:        story.URL,
+			Owners:     resource.OwnerNames(story, people),
+			AcceptedAt: story.AcceptedAt,
+		}
+
+		switch story.Type {
+		case tracker.StoryTypeFeature:
+			changelog.Features = append(changelog.Features, entry)
+		case tracker.StoryTypeBug:
+			changelog.Bugs = append(changelog.Bugs, entry)
+		case tracker.StoryTypeChore:
+			changelog.Chores = append(changelog.Chores, entry)
+		}
+	}
+
+	return changelog
+}
+
+func (c Changelog) sections() []changelogSection {
+	return []changelogSection{
+		{"Features", c.Features},
+		{"Bugs", c.Bugs},
+		{"Chores", c.Chores},
+	}
+}
+
+type changelogSection struct {
+	title   string
+	entries []ChangelogEntry
+}
+
+func (c Changelog) Markdown() string {
+	buffer := &bytes.Buffer{}
+	fmt.Fprintln(buffer, "# Changelog")
+
+	for _, section := range c.sections() {
+		if len(section.entries) == 0 {
+			continue
+		}
+
+		fmt.Fprintf(buffer, "\n## %s\n\n", section.title)
+		for _, entry := range section.entries {
+			fmt.Fprintf(buffer, "* [%s](%s)%s\n", entry.Name, entry.URL, ownedBy(entry))
+		}
+	}
+
+	return buffer.String()
+}
+
+func (c Changelog) Text() string {
+	buffer := &bytes.Buffer{}
+
+	for _, section := range c.sections() {
+		if len(section.entries) == 0 {
+			continue
+		}
+
+		if buffer.Len() > 0 {
+			fmt.Fprintln(buffer)
+		}
+
+		fmt.Fprintf(buffer, "%s:\n", section.title)
+		for _, entry := range section.entries {
+			fmt.Fprintf(buffer, "- %s%s\n  %s\n", entry.Name, ownedBy(entry), entry.URL)
+		}
+	}
+
+	return buffer.String()
+}
+
+func (c Changelog) Write(dir string) error {
+	contents, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	files := map[string]string{
+		"CHANGELOG.md":   c.Markdown(),
+		"changelog.json": string(contents) + "\n",
+		"changelog.txt":  c.Text(),
+	}
+
+	for name, contents := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(contents), 0644); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (c Changelog) Metadata() []resource.MetadataPair {
+	return []resource.MetadataPair{
+		{Name: "features", Value: fmt.Sprintf("%d", len(c.Features))},
+		{Name: "bugs", Value: fmt.Sprintf("%d", len(c.Bugs))},
+		{Name: "chores", Value: fmt.Sprintf("%d", len(c.Chores))},
+	}
+}
+
+func ownedBy(entry ChangelogEntry) string {
+	if len(entry.Owners) == 0 {
+		return ""
+	}
+
+	return " (" + strings.Join(entry.Owners, ", ") + ")"
+}
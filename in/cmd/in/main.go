@@ -1,38 +1,37 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"os"
-	"time"
 
 	"github.com/cjcjameson/tracker-story-resource"
 	"github.com/cjcjameson/tracker-story-resource/in"
 )
 
 func main() {
-	var request in.InRequest
-	if err := json.NewDecoder(os.Stdin).Decode(&request); err != nil {
-		fatal("reading request from stdin", err)
+	if len(os.Args) < 2 {
+		fmt.Fprintf(os.Stderr, "usage: %s <destination directory>\n", os.Args[0])
+		os.Exit(1)
 	}
 
-	timestamp := request.Version.Time
-	if timestamp.IsZero() {
-		timestamp = time.Now()
+	var request in.InRequest
+	if err := json.NewDecoder(os.Stdin).Decode(&request); err != nil {
+		fatal(fmt.Errorf("reading request from stdin: %s", err))
 	}
 
-	response := in.InResponse{
-		Version: resource.Version{
-			Time: timestamp,
-		},
+	response, err := in.Run(context.Background(), request, os.Args[1], resource.Env{Log: os.Stderr})
+	if err != nil {
+		fatal(err)
 	}
 
 	if err := json.NewEncoder(os.Stdout).Encode(response); err != nil {
-		fatal("writing response", err)
+		fatal(fmt.Errorf("writing response: %s", err))
 	}
 }
 
-func fatal(message string, err error) {
-	fmt.Fprintf(os.Stderr, "error %s: %s\n", message, err)
+func fatal(err error) {
+	fmt.Fprintf(os.Stderr, "error %s\n", err)
 	os.Exit(1)
 }
@@ -0,0 +1,60 @@
+package in
+
+import (
+	"fmt"
+
+	"github.com/XenoPhex/go-tracker"
+	"github.com/cjcjameson/tracker-story-resource"
+)
+
+func FetchEpicProgress(client resource.Reader, name string) (EpicProgress, error) {
+	epics, err := client.Epics()
+	if err != nil {
+		return EpicProgress{}, err
+	}
+
+	for _, epic := range epics {
+		if epic.Name != name {
+			continue
+		}
+
+		progress := EpicProgress{Epic: epic}
+		if epic.Label == nil {
+			return progress, nil
+		}
+
+		stories, err := resource.AllStories(client, tracker.StoriesQuery{
+			Label: epic.Label.Name,
+		})
+		if err != nil {
+			return EpicProgress{}, err
+		}
+
+		for _, story := range stories {
+			points := 0.0
+			if story.Estimate != nil {
+				points = *story.Estimate
+			}
+
+			progress.TotalStories++
+			progress.TotalPoints += points
+
+			if story.State == tracker.StoryStateAccepted {
+				progress.AcceptedStories++
+				progress.AcceptedPoints += points
+			}
+		}
+
+		return progress, nil
+	}
+
+	return EpicProgress{}, fmt.Errorf("epic not found: %s", name)
+}
+
+func (p EpicProgress) Metadata() []resource.MetadataPair {
+	return []resource.MetadataPair{
+		{Name: "epic", Value: p.Epic.Name},
+		{Name: "accepted_points", Value: fmt.Sprintf("%g/%g", p.AcceptedPoints, p.TotalPoints)},
+		{Name: "accepted_stories", Value: fmt.Sprintf("%d/%d", p.AcceptedStories, p.TotalStories)},
+	}
+}
@@ -0,0 +1,221 @@
+package in
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/XenoPhex/go-tracker"
+	"github.com/cjcjameson/tracker-story-resource"
+)
+
+// ExportFields asks Tracker for each story's comments, tasks and blockers
+// along with its usual fields.
+const ExportFields = ":default,comments,tasks,blockers"
+
+// ExportedStory is a story with its comments, tasks and blockers, and the
+// names of its owners for readers without access to the project.
+type ExportedStory struct {
+	tracker.Story
+	Owners []string `json:"owners,omitempty"`
+}
+
+type Export struct {
+	Stories []ExportedStory
+}
+
+// FetchExport collects every story matching the params, page by page.
+func FetchExport(client resource.Reader, params ExportParams) (Export, error) {
+	stories, err := resource.AllStories(client, tracker.StoriesQuery{
+		State:  params.State,
+		Label:  params.Label,
+		Filter: params.Filter,
+		Fields: ExportFields,
+	})
+	if err != nil {
+		return Export{}, err
+	}
+
+	people, err := resource.PeopleByID(client)
+	if err != nil {
+		return Export{}, err
+	}
+
+	export := Export{Stories: []ExportedStory{}}
+	for _, story := range stories {
+		export.Stories = append(export.Stories, ExportedStory{
+			Story:  story,
+			Owners: resource.OwnerNames(story, people),
+		})
+	}
+
+	return export, nil
+}
+
+func (e Export) JSON() ([]byte, error) {
+	contents, err := json.MarshalIndent(e.Stories, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	return append(contents, '\n'), nil
+}
+
+var exportColumns = []string{
+	"id", "type", "state", "name", "estimate", "labels", "owners",
+	"created_at", "accepted_at", "url", "description", "tasks", "comments",
+}
+
+// CSV has a row per story. Lists within a cell are one item per line, so
+// that spreadsheets show them as such.
+func (e Export) CSV() ([]byte, error) {
+	buffer := &bytes.Buffer{}
+	writer := csv.NewWriter(buffer)
+
+	if err := writer.Write(exportColumns); err != nil {
+		return nil, err
+	}
+
+	for _, story := range e.Stories {
+		var labels, tasks, comments []string
+		for _, label := range story.Labels {
+			labels = append(labels, label.Name)
+		}
+
+		for _, task := range story.Tasks {
+			tasks = append(tasks, checkbox(task)+" "+task.Description)
+		}
+
+		for _, comment := range story.Comments {
+			comments = append(comments, comment.Text)
+		}
+
+		err := writer.Write([]string{
+			strconv.Itoa(story.ID),
+			string(story.Type),
+			string(story.State),
+			story.Name,
+			estimate(story.Story),
+			strings.Join(labels, "\n"),
+			strings.Join(story.Owners, "\n"),
+			timestamp(story.CreatedAt),
+			timestamp(story.AcceptedAt),
+			story.URL,
+			story.Description,
+			strings.Join(tasks, "\n"),
+			strings.Join(comments, "\n\n"),
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	writer.Flush()
+	return buffer.Bytes(), writer.Error()
+}
+
+func (e Export) Markdown() string {
+	buffer := &bytes.Buffer{}
+	fmt.Fprintln(buffer, "# Stories")
+
+	for _, story := range e.Stories {
+		fmt.Fprintf(buffer, "\n## [#%d](%s) %s\n\n", story.ID, story.URL, story.Name)
+
+		details := []string{string(story.Type), string(story.State)}
+		if story.Estimate != nil {
+			details = append(details, estimate(story.Story)+" points")
+		}
+
+		if len(story.Owners) > 0 {
+			details = append(details, "owned by "+strings.Join(story.Owners, ", "))
+		}
+
+		for _, label := range story.Labels {
+			details = append(details, "`"+label.Name+"`")
+		}
+
+		fmt.Fprintf(buffer, "*%s*\n", strings.Join(details, " · "))
+
+		if story.Description != "" {
+			fmt.Fprintf(buffer, "\n%s\n", strings.TrimSpace(story.Description))
+		}
+
+		if len(story.Tasks) > 0 {
+			fmt.Fprint(buffer, "\n### Tasks\n\n")
+			for _, task := range story.Tasks {
+				fmt.Fprintf(buffer, "* %s %s\n", checkbox(task), task.Description)
+			}
+		}
+
+		if len(story.Comments) > 0 {
+			fmt.Fprintln(buffer, "\n### Comments")
+			for _, comment := range story.Comments {
+				fmt.Fprintf(buffer, "\n> %s\n", strings.Replace(strings.TrimSpace(comment.Text), "\n", "\n> ", -1))
+			}
+		}
+	}
+
+	return buffer.String()
+}
+
+func (e Export) Write(dir string) error {
+	contents, err := e.JSON()
+	if err != nil {
+		return err
+	}
+
+	table, err := e.CSV()
+	if err != nil {
+		return err
+	}
+
+	files := map[string][]byte{
+		"stories.json": contents,
+		"stories.csv":  table,
+		"stories.md":   []byte(e.Markdown()),
+	}
+
+	for name, contents := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), contents, 0644); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (e Export) Metadata() []resource.MetadataPair {
+	return []resource.MetadataPair{
+		{Name: "exported_stories", Value: fmt.Sprintf("%d", len(e.Stories))},
+	}
+}
+
+func checkbox(task tracker.Task) string {
+	if task.Complete {
+		return "[x]"
+	}
+
+	return "[ ]"
+}
+
+func estimate(story tracker.Story) string {
+	if story.Estimate == nil {
+		return ""
+	}
+
+	return strconv.FormatFloat(*story.Estimate, 'f', -1, 64)
+}
+
+func timestamp(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+
+	return t.UTC().Format(time.RFC3339)
+}
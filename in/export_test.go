@@ -0,0 +1,147 @@
+package in_test
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/XenoPhex/go-tracker"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/cjcjameson/tracker-story-resource"
+	"github.com/cjcjameson/tracker-story-resource/in"
+	"github.com/cjcjameson/tracker-story-resource/trackerfake"
+)
+
+var _ = Describe("Export", func() {
+	var (
+		fake        *trackerfake.Server
+		env         resource.Env
+		destination string
+		exhaust     tracker.Story
+	)
+
+	BeforeEach(func() {
+		fake = trackerfake.New("abc", 1234)
+
+		client, err := resource.NewProjectClient(fake.Source())
+		Expect(err).NotTo(HaveOccurred())
+
+		env = resource.Env{Client: client}
+
+		destination, err = ioutil.TempDir("", "in-export")
+		Expect(err).NotTo(HaveOccurred())
+
+		galen := fake.AddMember(tracker.Person{Name: "Galen Erso"}, "member")
+
+		estimate := 3.0
+		exhaust = fake.AddStory(tracker.Story{
+			Name:        "Cover the exhaust port",
+			Description: "It's two meters wide.",
+			Type:        tracker.StoryTypeBug,
+			State:       tracker.StoryStateStarted,
+			Estimate:    &estimate,
+			OwnerIDs:    []int{galen.ID},
+			Labels:      []tracker.Label{{Name: "reactor"}},
+			Tasks: []tracker.Task{
+				{Description: "Find the port", Complete: true},
+				{Description: "Weld a grate"},
+			},
+		})
+
+		_, err = client.CreateComment(exhaust.ID, tracker.Comment{Text: "Who would aim\nfor that?"})
+		Expect(err).NotTo(HaveOccurred())
+
+		// more than a page, to check every page is exported
+		for i := 0; i < 100; i++ {
+			fake.AddStory(tracker.Story{Name: fmt.Sprintf("Polish panel %d", i), Labels: []tracker.Label{{Name: "reactor"}}})
+		}
+
+		fake.AddStory(tracker.Story{Name: "Hire more stormtroopers"})
+	})
+
+	AfterEach(func() {
+		fake.Close()
+		os.RemoveAll(destination)
+	})
+
+	run := func() in.InResponse {
+		response, err := in.Run(context.Background(), in.InRequest{
+			Params: in.Params{Export: &in.ExportParams{Label: "reactor"}},
+		}, destination, env)
+		Expect(err).NotTo(HaveOccurred())
+		return response
+	}
+
+	It("writes every matching story with its comments, tasks, labels and owners as JSON", func() {
+		response := run()
+		Expect(response.Metadata).To(ContainElement(resource.MetadataPair{Name: "exported_stories", Value: "101"}))
+
+		contents, err := ioutil.ReadFile(filepath.Join(destination, "stories.json"))
+		Expect(err).NotTo(HaveOccurred())
+
+		var stories []in.ExportedStory
+		Expect(json.Unmarshal(contents, &stories)).To(Succeed())
+		Expect(stories).To(HaveLen(101))
+
+		story := stories[0]
+		Expect(story.ID).To(Equal(exhaust.ID))
+		Expect(story.Owners).To(Equal([]string{"Galen Erso"}))
+		Expect(story.Tasks).To(HaveLen(2))
+		Expect(story.Comments).To(HaveLen(1))
+		Expect(story.Comments[0].Text).To(Equal("Who would aim\nfor that?"))
+
+		Expect(fake.Requests()).To(ContainElement(ContainSubstring("fields=%3Adefault%2Ccomments%2Ctasks%2Cblockers")))
+	})
+
+	It("writes a row per story as CSV", func() {
+		run()
+
+		file, err := os.Open(filepath.Join(destination, "stories.csv"))
+		Expect(err).NotTo(HaveOccurred())
+		defer file.Close()
+
+		rows, err := csv.NewReader(file).ReadAll()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(rows).To(HaveLen(102))
+
+		Expect(rows[0]).To(Equal([]string{
+			"id", "type", "state", "name", "estimate", "labels", "owners",
+			"created_at", "accepted_at", "url", "description", "tasks", "comments",
+		}))
+		Expect(rows[1][1:7]).To(Equal([]string{"bug", "started", "Cover the exhaust port", "3", "reactor", "Galen Erso"}))
+		Expect(rows[1][11]).To(Equal("[x] Find the port\n[ ] Weld a grate"))
+		Expect(rows[1][12]).To(Equal("Who would aim\nfor that?"))
+	})
+
+	It("writes a readable Markdown summary", func() {
+		run()
+
+		contents, err := ioutil.ReadFile(filepath.Join(destination, "stories.md"))
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(string(contents)).To(HavePrefix(fmt.Sprintf(`# Stories
+
+## [#%d](%s) Cover the exhaust port
+
+*bug · started · 3 points · owned by Galen Erso · `+"`reactor`"+`*
+
+It's two meters wide.
+
+### Tasks
+
+* [x] Find the port
+* [ ] Weld a grate
+
+### Comments
+
+> Who would aim
+> for that?
+`, exhaust.ID, exhaust.URL)))
+	})
+})
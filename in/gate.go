@@ -0,0 +1,107 @@
+package in
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/XenoPhex/go-tracker"
+	"github.com/cjcjameson/tracker-story-resource"
+)
+
+// ScopedStories finds the stories a release gate applies to: those with the
+// scope's label, those it lists by ID, and those referenced by the commits in
+// its repo range. The repo is cloned from a URL or found at an absolute path,
+// as the get has no inputs. Release markers themselves are left out.
+func ScopedStories(client resource.Reader, scope Scope) ([]tracker.Story, error) {
+	if scope.Label == "" && len(scope.StoryIDs) == 0 && scope.Repo == "" {
+		return nil, errors.New("a label, story IDs or a repo must be given")
+	}
+
+	var stories []tracker.Story
+	seen := map[int]bool{}
+
+	add := func(story tracker.Story) {
+		if seen[story.ID] || story.Type == tracker.StoryTypeRelease {
+			return
+		}
+
+		seen[story.ID] = true
+		stories = append(stories, story)
+	}
+
+	if scope.Label != "" {
+		labeled, err := resource.AllStories(client, tracker.StoriesQuery{
+			Label: scope.Label,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		for _, story := range labeled {
+			add(story)
+		}
+	}
+
+	ids := scope.StoryIDs
+	if scope.Repo != "" {
+		repo, cleanup, err := checkout(scope.Repo)
+		if err != nil {
+			return nil, err
+		}
+		defer cleanup()
+
+		to := scope.To
+		if to == "" {
+			to = "HEAD"
+		}
+
+		messages, err := resource.CommitMessages(repo, scope.From, to)
+		if err != nil {
+			return nil, err
+		}
+
+		ids = append(ids, resource.StoryIDs(messages)...)
+	}
+
+	for _, id := range ids {
+		if seen[id] {
+			continue
+		}
+
+		story, err := client.Story(id)
+		if err != nil {
+			return nil, fmt.Errorf("fetching story %d: %s", id, err)
+		}
+
+		add(story)
+	}
+
+	return stories, nil
+}
+
+func NonCompliant(stories []tracker.Story, state tracker.StoryState) []tracker.Story {
+	var nonCompliant []tracker.Story
+	for _, story := range stories {
+		if story.State != state {
+			nonCompliant = append(nonCompliant, story)
+		}
+	}
+
+	return nonCompliant
+}
+
+func ComplianceError(stories []tracker.Story, state tracker.StoryState, people map[int]tracker.Person) error {
+	lines := []string{fmt.Sprintf("%d stories are not %s:", len(stories), state)}
+
+	for _, story := range stories {
+		owners := strings.Join(resource.OwnerNames(story, people), ", ")
+		if owners == "" {
+			owners = "no owner"
+		}
+
+		lines = append(lines, fmt.Sprintf("  #%d %s (%s, %s)", story.ID, story.Name, story.State, owners))
+	}
+
+	return errors.New(strings.Join(lines, "\n"))
+}
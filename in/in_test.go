@@ -4,14 +4,18 @@ import (
 	"bytes"
 	"encoding/json"
 	"io/ioutil"
+	"net/http"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"time"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gbytes"
 
 	"github.com/onsi/gomega/gexec"
+	"github.com/onsi/gomega/ghttp"
 
 	"github.com/cjcjameson/tracker-story-resource"
 	"github.com/cjcjameson/tracker-story-resource/in"
@@ -19,11 +23,17 @@ import (
 
 var _ = Describe("In", func() {
 	var (
-		tmpDir   string
-		request  in.InRequest
-		response in.InResponse
+		tmpDir         string
+		request        in.InRequest
+		response       in.InResponse
+		session        *gexec.Session
+		expectedStatus int
 	)
 
+	BeforeEach(func() {
+		expectedStatus = 0
+	})
+
 	JustBeforeEach(func() {
 		binPath, err := gexec.Build("github.com/cjcjameson/tracker-story-resource/in/cmd/in")
 		Expect(err).NotTo(HaveOccurred())
@@ -38,17 +48,19 @@ var _ = Describe("In", func() {
 		cmd.Stdin = stdin
 		cmd.Dir = tmpDir
 
-		session, err := gexec.Start(
+		session, err = gexec.Start(
 			cmd,
 			GinkgoWriter,
 			GinkgoWriter,
 		)
 		Expect(err).NotTo(HaveOccurred())
 
-		Eventually(session).Should(gexec.Exit(0))
+		Eventually(session).Should(gexec.Exit(expectedStatus))
 
-		err = json.Unmarshal(session.Out.Contents(), &response)
-		Expect(err).NotTo(HaveOccurred())
+		if expectedStatus == 0 {
+			err = json.Unmarshal(session.Out.Contents(), &response)
+			Expect(err).NotTo(HaveOccurred())
+		}
 	})
 
 	AfterEach(func() {
@@ -88,4 +100,349 @@ var _ = Describe("In", func() {
 			Expect(response.Version.Time).To(BeTemporally("~", time.Now(), time.Second))
 		})
 	})
+
+	Context("when an epic is requested", func() {
+		var server *ghttp.Server
+
+		BeforeEach(func() {
+			server = ghttp.NewServer()
+
+			request = in.InRequest{
+				Source: resource.Source{
+					Token:      "abc",
+					ProjectID:  "1234",
+					TrackerURL: server.URL(),
+				},
+				Params: in.Params{
+					Epic: "Death Star",
+				},
+			}
+
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/services/v5/projects/1234/epics"),
+					ghttp.VerifyHeaderKV("X-TrackerToken", "abc"),
+					ghttp.RespondWith(http.StatusOK, `[
+						{"id": 5, "name": "Tie Fighters", "label": {"id": 8, "name": "tie fighters"}},
+						{"id": 6, "name": "Death Star", "label": {"id": 9, "name": "death star"}}
+					]`),
+				),
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/services/v5/projects/1234/stories", "with_label=death+star&limit=100"),
+					ghttp.RespondWith(http.StatusOK, `[
+						{"id": 1, "current_state": "accepted", "estimate": 3},
+						{"id": 2, "current_state": "started", "estimate": 2},
+						{"id": 3, "current_state": "unscheduled"}
+					]`, http.Header{"X-Tracker-Pagination-Total": []string{"3"}}),
+				),
+			)
+		})
+
+		AfterEach(func() {
+			server.Close()
+		})
+
+		It("writes the epic with its progress", func() {
+			contents, err := ioutil.ReadFile(filepath.Join(tmpDir, "epic.json"))
+			Expect(err).NotTo(HaveOccurred())
+
+			var progress in.EpicProgress
+			err = json.Unmarshal(contents, &progress)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(progress.Epic.ID).To(Equal(6))
+			Expect(progress.AcceptedPoints).To(Equal(3.0))
+			Expect(progress.TotalPoints).To(Equal(5.0))
+			Expect(progress.AcceptedStories).To(Equal(1))
+			Expect(progress.TotalStories).To(Equal(3))
+		})
+
+		It("reports the progress as metadata", func() {
+			Expect(response.Metadata).To(ContainElement(resource.MetadataPair{Name: "accepted_points", Value: "3/5"}))
+		})
+	})
+
+	Context("when a changelog is requested", func() {
+		var server *ghttp.Server
+
+		BeforeEach(func() {
+			server = ghttp.NewServer()
+
+			request = in.InRequest{
+				Source: resource.Source{
+					Token:      "abc",
+					ProjectID:  "1234",
+					TrackerURL: server.URL(),
+				},
+				Version: resource.Version{
+					Time: time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC),
+				},
+				Params: in.Params{
+					Changelog: &in.ChangelogParams{
+						From: time.Date(2026, 9, 1, 0, 0, 0, 0, time.UTC),
+					},
+				},
+			}
+
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/services/v5/projects/1234/stories",
+						"with_state=accepted&accepted_after=2026-09-01T00:00:00Z&accepted_before=2026-10-01T00:00:00Z&limit=100"),
+					ghttp.RespondWith(http.StatusOK, `[
+						{"id": 1, "name": "Build the tractor beam", "story_type": "feature", "url": "http://localhost/story/show/1", "owner_ids": [101, 102]},
+						{"id": 2, "name": "Fix the exhaust port", "story_type": "bug", "url": "http://localhost/story/show/2", "owner_ids": [101]},
+						{"id": 3, "name": "Polish the helmets", "story_type": "chore", "url": "http://localhost/story/show/3"},
+						{"id": 4, "name": "Battlestation operational", "story_type": "release", "url": "http://localhost/story/show/4"}
+					]`, http.Header{"X-Tracker-Pagination-Total": []string{"4"}}),
+				),
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/services/v5/projects/1234/memberships"),
+					ghttp.RespondWith(http.StatusOK, `[
+						{"id": 1, "person": {"id": 101, "name": "Darth Vader"}},
+						{"id": 2, "person": {"id": 102, "name": "Grand Moff Tarkin"}}
+					]`),
+				),
+			)
+		})
+
+		AfterEach(func() {
+			server.Close()
+		})
+
+		It("writes the accepted stories grouped by type", func() {
+			contents, err := ioutil.ReadFile(filepath.Join(tmpDir, "changelog.json"))
+			Expect(err).NotTo(HaveOccurred())
+
+			var changelog in.Changelog
+			err = json.Unmarshal(contents, &changelog)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(changelog.Features).To(HaveLen(1))
+			Expect(changelog.Features[0].Owners).To(Equal([]string{"Darth Vader", "Grand Moff Tarkin"}))
+			Expect(changelog.Bugs).To(HaveLen(1))
+			Expect(changelog.Chores).To(HaveLen(1))
+		})
+
+		It("writes a markdown changelog", func() {
+			contents, err := ioutil.ReadFile(filepath.Join(tmpDir, "CHANGELOG.md"))
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(string(contents)).To(Equal(`# Changelog
+
+## Features
+
+* [Build the tractor beam](http://localhost/story/show/1) (Darth Vader, Grand Moff Tarkin)
+
+## Bugs
+
+* [Fix the exhaust port](http://localhost/story/show/2) (Darth Vader)
+
+## Chores
+
+* [Polish the helmets](http://localhost/story/show/3)
+`))
+		})
+
+		It("writes a plain text changelog", func() {
+			contents, err := ioutil.ReadFile(filepath.Join(tmpDir, "changelog.txt"))
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(string(contents)).To(ContainSubstring("Bugs:\n- Fix the exhaust port (Darth Vader)\n  http://localhost/story/show/2\n"))
+		})
+	})
+
+	Context("when a next version is requested", func() {
+		var (
+			server     *ghttp.Server
+			versionDir string
+		)
+
+		BeforeEach(func() {
+			server = ghttp.NewServer()
+
+			var err error
+			versionDir, err = ioutil.TempDir("", "tracker_story_resource_version")
+			Expect(err).NotTo(HaveOccurred())
+
+			err = ioutil.WriteFile(filepath.Join(versionDir, "number"), []byte("v1.4.2\n"), 0644)
+			Expect(err).NotTo(HaveOccurred())
+
+			request = in.InRequest{
+				Source: resource.Source{
+					Token:      "abc",
+					ProjectID:  "1234",
+					TrackerURL: server.URL(),
+				},
+				Params: in.Params{
+					NextVersion: &in.NextVersionParams{
+						VersionFile: filepath.Join(versionDir, "number"),
+						Since:       time.Date(2026, 9, 1, 0, 0, 0, 0, time.UTC),
+					},
+				},
+			}
+		})
+
+		AfterEach(func() {
+			server.Close()
+			os.RemoveAll(versionDir)
+		})
+
+		respondWith := func(delivered string, accepted string) {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/services/v5/projects/1234/stories", "with_state=delivered&limit=100"),
+					ghttp.RespondWith(http.StatusOK, delivered),
+				),
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/services/v5/projects/1234/stories", "with_state=accepted&accepted_after=2026-09-01T00:00:00Z&limit=100"),
+					ghttp.RespondWith(http.StatusOK, accepted),
+				),
+			)
+		}
+
+		nextVersion := func() string {
+			contents, err := ioutil.ReadFile(filepath.Join(tmpDir, "next_version"))
+			Expect(err).NotTo(HaveOccurred())
+			return string(contents)
+		}
+
+		Context("when only bugs and chores shipped", func() {
+			BeforeEach(func() {
+				respondWith(`[{"id": 1, "story_type": "bug"}]`, `[{"id": 2, "story_type": "chore"}]`)
+			})
+
+			It("suggests a patch release", func() {
+				Expect(nextVersion()).To(Equal("v1.4.3\n"))
+				Expect(response.Metadata).To(ContainElement(resource.MetadataPair{Name: "bump", Value: "patch"}))
+			})
+		})
+
+		Context("when a feature shipped", func() {
+			BeforeEach(func() {
+				respondWith(`[{"id": 1, "story_type": "bug"}]`, `[{"id": 2, "story_type": "feature"}]`)
+			})
+
+			It("suggests a minor release", func() {
+				Expect(nextVersion()).To(Equal("v1.5.0\n"))
+			})
+		})
+
+		Context("when a breaking story shipped", func() {
+			BeforeEach(func() {
+				respondWith(`[{"id": 1, "story_type": "chore", "labels": [{"name": "breaking"}]}]`, `[{"id": 2, "story_type": "feature"}]`)
+			})
+
+			It("suggests a major release", func() {
+				Expect(nextVersion()).To(Equal("v2.0.0\n"))
+			})
+		})
+
+		Context("when nothing shipped", func() {
+			BeforeEach(func() {
+				respondWith(`[]`, `[]`)
+			})
+
+			It("keeps the current version", func() {
+				Expect(nextVersion()).To(Equal("v1.4.2\n"))
+				Expect(response.Metadata).To(ContainElement(resource.MetadataPair{Name: "bump", Value: "none"}))
+			})
+		})
+	})
+
+	Context("when stories are required to be accepted", func() {
+		var (
+			server  *ghttp.Server
+			repoDir string
+		)
+
+		BeforeEach(func() {
+			server = ghttp.NewServer()
+
+			var err error
+			repoDir, err = ioutil.TempDir("", "tracker_story_resource_repo")
+			Expect(err).NotTo(HaveOccurred())
+
+			for _, args := range [][]string{
+				{"init"},
+				{"config", "user.email", "concourse@example.com"},
+				{"config", "user.name", "Concourse Tracker Resource"},
+				{"commit", "--allow-empty", "-m", "old work [Finishes #1]"},
+				{"tag", "v1.0.0"},
+				{"commit", "--allow-empty", "-m", "new work [Finishes #3]"},
+			} {
+				cmd := exec.Command("git", args...)
+				cmd.Dir = repoDir
+				Expect(cmd.Run()).To(Succeed())
+			}
+
+			request = in.InRequest{
+				Source: resource.Source{
+					Token:      "abc",
+					ProjectID:  "1234",
+					TrackerURL: server.URL(),
+				},
+				Params: in.Params{
+					RequireState: "accepted",
+					Scope: in.Scope{
+						Label:    "v1.1.0",
+						StoryIDs: []int{2},
+						Repo:     repoDir,
+						From:     "v1.0.0",
+					},
+				},
+			}
+
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/services/v5/projects/1234/stories", "with_label=v1.1.0&limit=100"),
+					ghttp.RespondWith(http.StatusOK, `[
+						{"id": 10, "name": "Release", "story_type": "release", "current_state": "unstarted"},
+						{"id": 2, "name": "Build the tractor beam", "story_type": "feature", "current_state": "accepted"}
+					]`),
+				),
+			)
+		})
+
+		AfterEach(func() {
+			server.Close()
+			os.RemoveAll(repoDir)
+		})
+
+		Context("when every story in scope is accepted", func() {
+			BeforeEach(func() {
+				server.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("GET", "/services/v5/projects/1234/stories/3"),
+						ghttp.RespondWith(http.StatusOK, `{"id": 3, "name": "Fix the exhaust port", "story_type": "bug", "current_state": "accepted"}`),
+					),
+				)
+			})
+
+			It("succeeds", func() {
+				Expect(response.Metadata).To(ContainElement(resource.MetadataPair{Name: "stories_accepted", Value: "2"}))
+			})
+		})
+
+		Context("when a story in scope is not accepted", func() {
+			BeforeEach(func() {
+				expectedStatus = 1
+
+				server.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("GET", "/services/v5/projects/1234/stories/3"),
+						ghttp.RespondWith(http.StatusOK, `{"id": 3, "name": "Fix the exhaust port", "story_type": "bug", "current_state": "delivered", "owner_ids": [101]}`),
+					),
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("GET", "/services/v5/projects/1234/memberships"),
+						ghttp.RespondWith(http.StatusOK, `[{"id": 1, "person": {"id": 101, "name": "Darth Vader"}}]`),
+					),
+				)
+			})
+
+			It("fails listing the story with its state and owner", func() {
+				Expect(session.Err).To(Say("1 stories are not accepted:"))
+				Expect(session.Err).To(Say(`#3 Fix the exhaust port \(delivered, Darth Vader\)`))
+			})
+		})
+	})
 })
@@ -1,13 +1,69 @@
 package in
 
-import "github.com/cjcjameson/tracker-story-resource"
+import (
+	"time"
+
+	"github.com/XenoPhex/go-tracker"
+	"github.com/cjcjameson/tracker-story-resource"
+)
 
 type InRequest struct {
 	Source  resource.Source  `json:"source"`
 	Version resource.Version `json:"version"`
+	Params  Params           `json:"params"`
+}
+
+type Params struct {
+	Epic        string             `json:"epic"`
+	Changelog   *ChangelogParams   `json:"changelog"`
+	NextVersion *NextVersionParams `json:"next_version"`
+	Export      *ExportParams      `json:"export"`
+
+	RequireState tracker.StoryState `json:"require_state"`
+	Scope        Scope              `json:"scope"`
+}
+
+type Scope struct {
+	Label    string `json:"label"`
+	StoryIDs []int  `json:"story_ids"`
+
+	Repo string `json:"repo"`
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+type ChangelogParams struct {
+	From  time.Time `json:"from"`
+	To    time.Time `json:"to"`
+	Label string    `json:"label"`
+}
+
+type NextVersionParams struct {
+	CurrentVersion string `json:"current_version"`
+	Repo           string `json:"repo"`
+	VersionFile    string `json:"version_file"`
+
+	Since time.Time `json:"since"`
+	Label string    `json:"label"`
+}
+
+type ExportParams struct {
+	Filter string             `json:"filter"`
+	Label  string             `json:"label"`
+	State  tracker.StoryState `json:"state"`
 }
 
 type InResponse struct {
 	Version  resource.Version        `json:"version"`
 	Metadata []resource.MetadataPair `json:"metadata"`
 }
+
+type EpicProgress struct {
+	Epic tracker.Epic `json:"epic"`
+
+	AcceptedPoints float64 `json:"accepted_points"`
+	TotalPoints    float64 `json:"total_points"`
+
+	AcceptedStories int `json:"accepted_stories"`
+	TotalStories    int `json:"total_stories"`
+}
@@ -0,0 +1,36 @@
+package in
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/cjcjameson/tracker-story-resource"
+)
+
+// checkout finds a repository named in the get's params. A get starts with an
+// empty destination, so the repository is either cloned from a URL into a
+// temporary directory or used in place from an absolute path, e.g. one given
+// to the CLI. The returned function removes the clone.
+func checkout(repo string) (string, func(), error) {
+	if filepath.IsAbs(repo) {
+		return repo, func() {}, nil
+	}
+
+	if resource.IsRemote(repo) {
+		dir, err := ioutil.TempDir("", "tracker-story-repo")
+		if err != nil {
+			return "", nil, err
+		}
+
+		if err := resource.Clone(repo, dir); err != nil {
+			os.RemoveAll(dir)
+			return "", nil, err
+		}
+
+		return dir, func() { os.RemoveAll(dir) }, nil
+	}
+
+	return "", nil, fmt.Errorf("repo must be a git URL or an absolute path, as the get has no inputs: %s", repo)
+}
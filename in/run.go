@@ -0,0 +1,167 @@
+package in
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/cjcjameson/tracker-story-resource"
+)
+
+// Run gets the request's version, writing the files its params ask for to
+// the destination directory.
+func Run(ctx context.Context, request InRequest, destination string, env resource.Env) (InResponse, error) {
+	timestamp := request.Version.Time
+	if timestamp.IsZero() {
+		timestamp = env.Time()
+	}
+
+	response := InResponse{
+		Version: resource.Version{
+			Time: timestamp,
+		},
+	}
+
+	var client resource.Reader
+	params := request.Params
+	if params.Epic != "" || params.Changelog != nil || params.NextVersion != nil || params.Export != nil || params.RequireState != "" {
+		var err error
+		client, err = env.ProjectClient(ctx, request.Source)
+		if err != nil {
+			return InResponse{}, err
+		}
+	}
+
+	if params.RequireState != "" {
+		stories, err := ScopedStories(client, params.Scope)
+		if err != nil {
+			return InResponse{}, fmt.Errorf("finding scoped stories: %s", err)
+		}
+
+		nonCompliant := NonCompliant(stories, params.RequireState)
+		if len(nonCompliant) > 0 {
+			people, err := resource.PeopleByID(client)
+			if err != nil {
+				return InResponse{}, fmt.Errorf("fetching project members: %s", err)
+			}
+
+			return InResponse{}, fmt.Errorf("checking story states: %s", ComplianceError(nonCompliant, params.RequireState, people))
+		}
+
+		response.Metadata = append(response.Metadata, resource.MetadataPair{
+			Name:  "stories_" + string(params.RequireState),
+			Value: fmt.Sprintf("%d", len(stories)),
+		})
+	}
+
+	if err := ctx.Err(); err != nil {
+		return InResponse{}, err
+	}
+
+	if params.Epic != "" {
+		progress, err := FetchEpicProgress(client, params.Epic)
+		if err != nil {
+			return InResponse{}, fmt.Errorf("fetching epic: %s", err)
+		}
+
+		if err := writeJSON(filepath.Join(destination, "epic.json"), progress); err != nil {
+			return InResponse{}, fmt.Errorf("writing epic: %s", err)
+		}
+
+		response.Metadata = append(response.Metadata, progress.Metadata()...)
+	}
+
+	if err := ctx.Err(); err != nil {
+		return InResponse{}, err
+	}
+
+	if params.Changelog != nil {
+		changelog, err := FetchChangelog(client, *params.Changelog, timestamp)
+		if err != nil {
+			return InResponse{}, fmt.Errorf("fetching changelog: %s", err)
+		}
+
+		if err := changelog.Write(destination); err != nil {
+			return InResponse{}, fmt.Errorf("writing changelog: %s", err)
+		}
+
+		response.Metadata = append(response.Metadata, changelog.Metadata()...)
+	}
+
+	if err := ctx.Err(); err != nil {
+		return InResponse{}, err
+	}
+
+	if params.NextVersion != nil {
+		metadata, err := writeNextVersion(client, *params.NextVersion, destination)
+		if err != nil {
+			return InResponse{}, err
+		}
+
+		response.Metadata = append(response.Metadata, metadata...)
+	}
+
+	if err := ctx.Err(); err != nil {
+		return InResponse{}, err
+	}
+
+	if params.Export != nil {
+		export, err := FetchExport(client, *params.Export)
+		if err != nil {
+			return InResponse{}, fmt.Errorf("fetching stories to export: %s", err)
+		}
+
+		if err := export.Write(destination); err != nil {
+			return InResponse{}, fmt.Errorf("writing export: %s", err)
+		}
+
+		response.Metadata = append(response.Metadata, export.Metadata()...)
+	}
+
+	return response, nil
+}
+
+func writeNextVersion(client resource.Reader, params NextVersionParams, destination string) ([]resource.MetadataPair, error) {
+	current, released, err := CurrentVersion(params)
+	if err != nil {
+		return nil, fmt.Errorf("reading current version: %s", err)
+	}
+
+	if params.Since.IsZero() {
+		params.Since = released
+	}
+
+	stories, err := ShippedStories(client, params)
+	if err != nil {
+		return nil, fmt.Errorf("fetching shipped stories: %s", err)
+	}
+
+	bump := BumpFor(stories)
+	next := current.Bump(bump)
+
+	if err := ioutil.WriteFile(filepath.Join(destination, "next_version"), []byte(next.String()+"\n"), 0644); err != nil {
+		return nil, fmt.Errorf("writing next version: %s", err)
+	}
+
+	if err := ioutil.WriteFile(filepath.Join(destination, "bump"), []byte(string(bump)+"\n"), 0644); err != nil {
+		return nil, fmt.Errorf("writing bump: %s", err)
+	}
+
+	return []resource.MetadataPair{
+		{Name: "next_version", Value: next.String()},
+		{Name: "bump", Value: string(bump)},
+	}, nil
+}
+
+func writeJSON(path string, value interface{}) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	return json.NewEncoder(file).Encode(value)
+}
@@ -0,0 +1,234 @@
+package in_test
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"time"
+
+	"github.com/XenoPhex/go-tracker"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/cjcjameson/tracker-story-resource"
+	"github.com/cjcjameson/tracker-story-resource/in"
+	"github.com/cjcjameson/tracker-story-resource/trackerfake"
+)
+
+var _ = Describe("Run", func() {
+	var (
+		fake        *trackerfake.Server
+		env         resource.Env
+		destination string
+		now         time.Time
+	)
+
+	BeforeEach(func() {
+		fake = trackerfake.New("abc", 1234)
+
+		client, err := resource.NewProjectClient(fake.Source())
+		Expect(err).NotTo(HaveOccurred())
+
+		now = time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+		env = resource.Env{
+			Client: client,
+			Now:    func() time.Time { return now },
+		}
+
+		destination, err = ioutil.TempDir("", "in-run")
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		fake.Close()
+		os.RemoveAll(destination)
+	})
+
+	It("stamps the version with the clock when there is none", func() {
+		response, err := in.Run(context.Background(), in.InRequest{}, destination, env)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(response.Version.Time).To(Equal(now))
+	})
+
+	It("fetches epic progress through the injected client", func() {
+		fake.AddEpic(tracker.Epic{Name: "Death Star"})
+		fake.AddStory(tracker.Story{Name: "Exhaust port", State: tracker.StoryStateAccepted, Labels: []tracker.Label{{Name: "Death Star"}}})
+
+		response, err := in.Run(context.Background(), in.InRequest{
+			Params: in.Params{Epic: "Death Star"},
+		}, destination, env)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(response.Metadata).NotTo(BeEmpty())
+
+		_, err = os.Stat(filepath.Join(destination, "epic.json"))
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("bounds a labeled changelog by its end time", func() {
+		before := now.Add(-48 * time.Hour)
+		after := now.Add(-time.Hour)
+		fake.AddStory(tracker.Story{Name: "Exhaust port", State: tracker.StoryStateAccepted, AcceptedAt: &before, Labels: []tracker.Label{{Name: "v1.0.0"}}})
+		fake.AddStory(tracker.Story{Name: "Tractor beam", State: tracker.StoryStateAccepted, AcceptedAt: &after, Labels: []tracker.Label{{Name: "v1.0.0"}}})
+
+		changelog, err := in.FetchChangelog(env.Client, in.ChangelogParams{
+			Label: "v1.0.0",
+			To:    now.Add(-24 * time.Hour),
+		}, now)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(changelog.Features).To(HaveLen(1))
+		Expect(changelog.Features[0].Name).To(Equal("Exhaust port"))
+	})
+
+	Describe("suggesting the next version", func() {
+		BeforeEach(func() {
+			fake.AddStory(tracker.Story{Name: "Tractor beam", Type: tracker.StoryTypeFeature, State: tracker.StoryStateDelivered})
+		})
+
+		nextVersion := func(params in.NextVersionParams) (string, error) {
+			_, err := in.Run(context.Background(), in.InRequest{
+				Params: in.Params{NextVersion: &params},
+			}, destination, env)
+			if err != nil {
+				return "", err
+			}
+
+			contents, err := ioutil.ReadFile(filepath.Join(destination, "next_version"))
+			Expect(err).NotTo(HaveOccurred())
+			return string(contents), nil
+		}
+
+		tagged := func(commits ...[]string) string {
+			repo, err := ioutil.TempDir("", "in-run-repo")
+			Expect(err).NotTo(HaveOccurred())
+
+			for _, args := range append([][]string{
+				{"init"},
+				{"config", "user.email", "concourse@example.com"},
+				{"config", "user.name", "Concourse Tracker Resource"},
+			}, commits...) {
+				cmd := exec.Command("git", args...)
+				cmd.Dir = repo
+				cmd.Env = append(os.Environ(), "GIT_COMMITTER_DATE=2026-10-18T12:00:00Z")
+				Expect(cmd.Run()).To(Succeed())
+			}
+
+			return repo
+		}
+
+		It("bumps the version given in the params", func() {
+			Expect(nextVersion(in.NextVersionParams{CurrentVersion: "v1.4.2", Since: now.Add(-24 * time.Hour)})).To(Equal("v1.5.0\n"))
+		})
+
+		It("counts the stories accepted since the last version", func() {
+			fake.RemoveStory(fake.Stories()[0].ID)
+
+			before := now.Add(-48 * time.Hour)
+			after := now.Add(-time.Hour)
+			fake.AddStory(tracker.Story{Name: "Exhaust port", Type: tracker.StoryTypeFeature, State: tracker.StoryStateAccepted, AcceptedAt: &before})
+			fake.AddStory(tracker.Story{Name: "Shield", Type: tracker.StoryTypeBug, State: tracker.StoryStateAccepted, AcceptedAt: &after})
+
+			Expect(nextVersion(in.NextVersionParams{CurrentVersion: "v1.4.2", Since: now.Add(-24 * time.Hour)})).To(Equal("v1.4.3\n"))
+		})
+
+		It("refuses to guess which stories shipped without a starting point", func() {
+			_, err := nextVersion(in.NextVersionParams{CurrentVersion: "v1.4.2"})
+			Expect(err).To(MatchError("fetching shipped stories: no starting point: give since or label, or a repo with a release tag"))
+		})
+
+		It("bumps the latest tag of a cloned repo, counting what was accepted since it", func() {
+			repo := tagged(
+				[]string{"commit", "--allow-empty", "-m", "first release"},
+				[]string{"tag", "2.0.1"},
+			)
+			defer os.RemoveAll(repo)
+
+			fake.RemoveStory(fake.Stories()[0].ID)
+
+			before := time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+			after := time.Date(2026, 10, 18, 18, 0, 0, 0, time.UTC)
+			fake.AddStory(tracker.Story{Name: "Exhaust port", Type: tracker.StoryTypeFeature, State: tracker.StoryStateAccepted, AcceptedAt: &before})
+			fake.AddStory(tracker.Story{Name: "Shield", Type: tracker.StoryTypeBug, State: tracker.StoryStateAccepted, AcceptedAt: &after})
+
+			Expect(nextVersion(in.NextVersionParams{Repo: "file://" + repo})).To(Equal("2.0.2\n"))
+		})
+
+		It("skips pre-release tags", func() {
+			repo := tagged(
+				[]string{"commit", "--allow-empty", "-m", "first release"},
+				[]string{"tag", "v2.0.1"},
+				[]string{"commit", "--allow-empty", "-m", "release candidate"},
+				[]string{"tag", "v2.1.0-rc.1"},
+			)
+			defer os.RemoveAll(repo)
+
+			Expect(nextVersion(in.NextVersionParams{Repo: "file://" + repo})).To(Equal("v2.1.0\n"))
+		})
+
+		It("refuses a version file relative to the empty destination", func() {
+			_, err := nextVersion(in.NextVersionParams{VersionFile: "version/number"})
+			Expect(err).To(MatchError("reading current version: version_file must be an absolute path, as the get has no inputs: version/number"))
+		})
+	})
+
+	Describe("gating on the stories of a repo range", func() {
+		var repo string
+
+		BeforeEach(func() {
+			story := fake.AddStory(tracker.Story{Name: "Exhaust port", State: tracker.StoryStateAccepted})
+
+			var err error
+			repo, err = ioutil.TempDir("", "in-run-repo")
+			Expect(err).NotTo(HaveOccurred())
+
+			for _, args := range [][]string{
+				{"init"},
+				{"config", "user.email", "concourse@example.com"},
+				{"config", "user.name", "Concourse Tracker Resource"},
+				{"commit", "--allow-empty", "-m", fmt.Sprintf("close it [Finishes #%d]", story.ID)},
+			} {
+				cmd := exec.Command("git", args...)
+				cmd.Dir = repo
+				Expect(cmd.Run()).To(Succeed())
+			}
+		})
+
+		AfterEach(func() {
+			os.RemoveAll(repo)
+		})
+
+		gate := func(repo string) (in.InResponse, error) {
+			return in.Run(context.Background(), in.InRequest{
+				Params: in.Params{
+					RequireState: tracker.StoryStateAccepted,
+					Scope:        in.Scope{Repo: repo},
+				},
+			}, destination, env)
+		}
+
+		It("clones a repo given by URL", func() {
+			response, err := gate("file://" + repo)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(response.Metadata).To(ContainElement(resource.MetadataPair{Name: "stories_accepted", Value: "1"}))
+		})
+
+		It("refuses a repo relative to the empty destination", func() {
+			_, err := gate("repo")
+			Expect(err).To(MatchError("finding scoped stories: repo must be a git URL or an absolute path, as the get has no inputs: repo"))
+		})
+	})
+
+	It("returns errors instead of exiting", func() {
+		fake.AddStory(tracker.Story{Name: "Exhaust port", State: tracker.StoryStateStarted, Labels: []tracker.Label{{Name: "v1.0.0"}}})
+
+		_, err := in.Run(context.Background(), in.InRequest{
+			Params: in.Params{
+				RequireState: tracker.StoryStateAccepted,
+				Scope:        in.Scope{Label: "v1.0.0"},
+			},
+		}, destination, env)
+		Expect(err).To(MatchError(HavePrefix("checking story states: 1 stories are not accepted")))
+	})
+})
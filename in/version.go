@@ -0,0 +1,197 @@
+package in
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/XenoPhex/go-tracker"
+	"github.com/cjcjameson/tracker-story-resource"
+)
+
+const breakingLabel = "breaking"
+
+type Bump string
+
+const (
+	BumpNone  Bump = "none"
+	BumpPatch Bump = "patch"
+	BumpMinor Bump = "minor"
+	BumpMajor Bump = "major"
+)
+
+type SemanticVersion struct {
+	Prefix string
+	Major  int
+	Minor  int
+	Patch  int
+}
+
+func ParseVersion(version string) (SemanticVersion, error) {
+	version = strings.TrimSpace(version)
+
+	var parsed SemanticVersion
+	if strings.HasPrefix(version, "v") {
+		parsed.Prefix = "v"
+		version = version[1:]
+	}
+
+	parts := strings.Split(version, ".")
+	if len(parts) != 3 {
+		return SemanticVersion{}, fmt.Errorf("invalid version: %s", version)
+	}
+
+	numbers := make([]int, len(parts))
+	for i, part := range parts {
+		number, err := strconv.Atoi(part)
+		if err != nil || number < 0 {
+			return SemanticVersion{}, fmt.Errorf("invalid version: %s", version)
+		}
+
+		numbers[i] = number
+	}
+
+	parsed.Major, parsed.Minor, parsed.Patch = numbers[0], numbers[1], numbers[2]
+	return parsed, nil
+}
+
+func ReadVersion(path string) (SemanticVersion, error) {
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		return SemanticVersion{}, err
+	}
+
+	return ParseVersion(string(contents))
+}
+
+// CurrentVersion finds the version to bump: the one given, the latest release
+// tag in a repo, or the contents of a file at an absolute path. For a tag it
+// also returns when the tagged commit was made, which is when the version
+// shipped; otherwise that time is zero.
+func CurrentVersion(params NextVersionParams) (SemanticVersion, time.Time, error) {
+	switch {
+	case params.CurrentVersion != "":
+		version, err := ParseVersion(params.CurrentVersion)
+		return version, time.Time{}, err
+	case params.Repo != "":
+		repo, cleanup, err := checkout(params.Repo)
+		if err != nil {
+			return SemanticVersion{}, time.Time{}, err
+		}
+		defer cleanup()
+
+		tag, err := resource.LatestTag(repo, "HEAD")
+		if err != nil {
+			return SemanticVersion{}, time.Time{}, err
+		}
+
+		// pre-releases such as v1.2.0-rc.1 are not the version to bump
+		for tag != "" && isPreRelease(tag) {
+			tag, err = resource.PreviousTag(repo, tag)
+			if err != nil {
+				return SemanticVersion{}, time.Time{}, err
+			}
+		}
+
+		if tag == "" {
+			return SemanticVersion{}, time.Time{}, fmt.Errorf("no release tags found in %s", params.Repo)
+		}
+
+		version, err := ParseVersion(tag)
+		if err != nil {
+			return SemanticVersion{}, time.Time{}, err
+		}
+
+		released, err := resource.CommitTime(repo, tag)
+		if err != nil {
+			return SemanticVersion{}, time.Time{}, err
+		}
+
+		return version, released, nil
+	case params.VersionFile != "":
+		if !filepath.IsAbs(params.VersionFile) {
+			return SemanticVersion{}, time.Time{}, fmt.Errorf("version_file must be an absolute path, as the get has no inputs: %s", params.VersionFile)
+		}
+
+		version, err := ReadVersion(params.VersionFile)
+		return version, time.Time{}, err
+	}
+
+	return SemanticVersion{}, time.Time{}, errors.New("current_version, repo or version_file must be given")
+}
+
+// isPreRelease reports whether a tag has a semver pre-release or build
+// suffix, e.g. v1.2.0-rc.1.
+func isPreRelease(tag string) bool {
+	return strings.ContainsAny(tag, "-+")
+}
+
+func (v SemanticVersion) Bump(bump Bump) SemanticVersion {
+	switch bump {
+	case BumpMajor:
+		v.Major, v.Minor, v.Patch = v.Major+1, 0, 0
+	case BumpMinor:
+		v.Minor, v.Patch = v.Minor+1, 0
+	case BumpPatch:
+		v.Patch++
+	}
+
+	return v
+}
+
+func (v SemanticVersion) String() string {
+	return fmt.Sprintf("%s%d.%d.%d", v.Prefix, v.Major, v.Minor, v.Patch)
+}
+
+// BumpFor applies semver rules to what was shipped: a story labeled
+// "breaking" is a major change, any feature is a minor one and anything else
+// is a patch.
+func BumpFor(stories []tracker.Story) Bump {
+	bump := BumpNone
+	for _, story := range stories {
+		switch {
+		case resource.HasLabel(story, breakingLabel):
+			return BumpMajor
+		case story.Type == tracker.StoryTypeFeature:
+			bump = BumpMinor
+		case story.Type == tracker.StoryTypeRelease:
+		case bump == BumpNone:
+			bump = BumpPatch
+		}
+	}
+
+	return bump
+}
+
+// ShippedStories returns the stories delivered or accepted since the last
+// version: every delivered story and the stories accepted since the given
+// time, optionally only those with a label. Without a time only a label can
+// tell the last version's stories apart, so one of them is required.
+func ShippedStories(client resource.Reader, params NextVersionParams) ([]tracker.Story, error) {
+	if params.Since.IsZero() && params.Label == "" {
+		return nil, errors.New("no starting point: give since or label, or a repo with a release tag")
+	}
+
+	delivered, err := resource.AllStories(client, tracker.StoriesQuery{
+		State: tracker.StoryStateDelivered,
+		Label: params.Label,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	accepted, err := resource.AllStories(client, tracker.StoriesQuery{
+		State:         tracker.StoryStateAccepted,
+		Label:         params.Label,
+		AcceptedAfter: params.Since,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return append(delivered, accepted...), nil
+}
@@ -0,0 +1,147 @@
+package out
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+type alertmanagerWebhook struct {
+	Alerts []alert `json:"alerts"`
+}
+
+type alert struct {
+	Status       string            `json:"status"`
+	Labels       map[string]string `json:"labels"`
+	Annotations  map[string]string `json:"annotations"`
+	StartsAt     time.Time         `json:"startsAt"`
+	EndsAt       time.Time         `json:"endsAt"`
+	GeneratorURL string            `json:"generatorURL"`
+	Fingerprint  string            `json:"fingerprint"`
+}
+
+// Resolution is a finding that has gone away, such as an alert that stopped
+// firing.
+type Resolution struct {
+	Fingerprint string
+	At          time.Time
+}
+
+// ParseAlertmanager reads the payload of a Prometheus Alertmanager webhook.
+// Each firing alert becomes a bug; resolved alerts are reported as
+// resolutions.
+func ParseAlertmanager(contents []byte) (Report, error) {
+	var webhook alertmanagerWebhook
+	if err := json.Unmarshal(contents, &webhook); err != nil {
+		return Report{}, fmt.Errorf("invalid alertmanager webhook: %s", err)
+	}
+
+	var report Report
+	for _, alert := range webhook.Alerts {
+		fingerprint := "alert:" + alert.fingerprint()
+
+		if alert.Status == "resolved" {
+			report.Resolved = append(report.Resolved, Resolution{
+				Fingerprint: fingerprint,
+				At:          alert.EndsAt,
+			})
+			continue
+		}
+
+		severity := strings.ToLower(alert.Labels["severity"])
+		if severity == "" {
+			severity = unknownSeverity
+		}
+
+		report.Findings = append(report.Findings, Finding{
+			Fingerprint: fingerprint,
+			Name:        alert.name(),
+			Description: alert.description(),
+			Labels:      []string{"alert", "severity:" + severity},
+
+			// Alertmanager repeats firing alerts until they resolve
+			Standing: true,
+		})
+	}
+
+	return report, nil
+}
+
+// fingerprint falls back to the alert's labels for senders that leave
+// Alertmanager's fingerprint out.
+func (a alert) fingerprint() string {
+	if a.Fingerprint != "" {
+		return a.Fingerprint
+	}
+
+	var pairs []string
+	for _, name := range a.labelNames() {
+		pairs = append(pairs, name+"="+a.Labels[name])
+	}
+
+	return strings.Join(pairs, ",")
+}
+
+func (a alert) name() string {
+	name := a.Labels["alertname"]
+	if summary := a.Annotations["summary"]; summary != "" {
+		if name == "" {
+			return summary
+		}
+
+		return name + ": " + summary
+	}
+
+	return name
+}
+
+func (a alert) description() string {
+	var lines []string
+	if description := a.Annotations["description"]; description != "" {
+		lines = append(lines, description, "")
+	}
+
+	if !a.StartsAt.IsZero() {
+		lines = append(lines, "* Firing since: "+a.StartsAt.UTC().Format(time.RFC3339))
+	}
+
+	if a.GeneratorURL != "" {
+		lines = append(lines, "* Source: "+a.GeneratorURL)
+	}
+
+	for _, name := range a.labelNames() {
+		lines = append(lines, fmt.Sprintf("* %s: %s", name, a.Labels[name]))
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+func (a alert) labelNames() []string {
+	var names []string
+	for name := range a.Labels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// FindingPosition is where new findings are filed. Production alerts go to
+// the top of the backlog unless another position is given.
+func (p Params) FindingPosition() Position {
+	if p.Format == "alertmanager" && p.Position == (Position{}) {
+		return Position{TopOfBacklog: true}
+	}
+
+	return p.Position
+}
+
+func ResolvedComment(at time.Time) string {
+	if at.IsZero() {
+		return "Resolved."
+	}
+
+	return fmt.Sprintf("Resolved at %s.", at.UTC().Format(time.RFC3339))
+}
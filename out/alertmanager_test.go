@@ -0,0 +1,63 @@
+package out_test
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/cjcjameson/tracker-story-resource/out"
+)
+
+var _ = Describe("Parsing Alertmanager webhooks", func() {
+	var report out.Report
+
+	BeforeEach(func() {
+		var err error
+		report, err = out.ParseAlertmanager([]byte(Fixture("alertmanager.json")))
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("files a bug for each firing alert", func() {
+		Expect(report.Findings).To(HaveLen(2))
+
+		finding := report.Findings[0]
+		Expect(finding.Fingerprint).To(Equal("alert:3f2a1b"))
+		Expect(finding.Name).To(Equal("ReactorOverheating: Reactor core above 900K"))
+		Expect(finding.Labels).To(Equal([]string{"alert", "severity:critical"}))
+		Expect(finding.Standing).To(BeTrue())
+		Expect(finding.Description).To(Equal(`The core of reactor-1 has been above 900K for 5 minutes.
+
+* Firing since: 2026-10-19T08:00:00Z
+* Source: http://prometheus.example.com/graph?g0.expr=reactor_temperature
+* alertname: ReactorOverheating
+* instance: reactor-1
+* severity: Critical`))
+	})
+
+	It("fingerprints alerts without one by their labels", func() {
+		finding := report.Findings[1]
+		Expect(finding.Fingerprint).To(Equal("alert:alertname=ExhaustPortOpen,instance=port-2"))
+		Expect(finding.Name).To(Equal("ExhaustPortOpen"))
+		Expect(finding.Labels).To(Equal([]string{"alert", "severity:unknown"}))
+	})
+
+	It("reports resolved alerts", func() {
+		Expect(report.Resolved).To(Equal([]out.Resolution{
+			{Fingerprint: "alert:9c8d7e", At: time.Date(2026, 10, 19, 9, 0, 0, 0, time.UTC)},
+		}))
+	})
+
+	It("rejects payloads that are not a webhook", func() {
+		_, err := out.ParseAlertmanager([]byte("<html>"))
+		Expect(err).To(MatchError(ContainSubstring("invalid alertmanager webhook")))
+	})
+
+	It("files alerts at the top of the backlog unless told otherwise", func() {
+		params := out.Params{Format: "alertmanager"}
+		Expect(params.FindingPosition()).To(Equal(out.Position{TopOfBacklog: true}))
+
+		params.Position = out.Position{IntoCurrentIteration: true}
+		Expect(params.FindingPosition()).To(Equal(out.Position{IntoCurrentIteration: true}))
+	})
+})
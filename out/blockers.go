@@ -0,0 +1,105 @@
+package out
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Ordered returns the manifest's stories with every story after the local
+// stories blocking it. References are checked and cycles reported here so
+// that a bad manifest fails before anything is written to Tracker.
+func (m Manifest) Ordered() ([]ManifestStory, error) {
+	byID := map[string]int{}
+	for i, story := range m.Stories {
+		if story.ID == "" {
+			continue
+		}
+
+		if _, found := byID[story.ID]; found {
+			return nil, fmt.Errorf("duplicate story id: %s", story.ID)
+		}
+
+		byID[story.ID] = i
+	}
+
+	for _, story := range m.Stories {
+		for _, ref := range story.BlockedBy {
+			if _, local := byID[ref]; local {
+				continue
+			}
+
+			if _, err := storyIDReference(ref); err != nil {
+				return nil, fmt.Errorf("unknown blocker reference: %s", ref)
+			}
+		}
+	}
+
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+
+	marks := make([]int, len(m.Stories))
+	ordered := make([]ManifestStory, 0, len(m.Stories))
+
+	var visit func(i int, path []string) error
+	visit = func(i int, path []string) error {
+		story := m.Stories[i]
+		path = append(path, story.ID)
+
+		switch marks[i] {
+		case visited:
+			return nil
+		case visiting:
+			return fmt.Errorf("blocker cycle: %s", strings.Join(path, " -> "))
+		}
+
+		marks[i] = visiting
+		for _, ref := range story.BlockedBy {
+			if blocker, local := byID[ref]; local {
+				if err := visit(blocker, path); err != nil {
+					return err
+				}
+			}
+		}
+		marks[i] = visited
+
+		ordered = append(ordered, story)
+		return nil
+	}
+
+	for i := range m.Stories {
+		if err := visit(i, nil); err != nil {
+			return nil, err
+		}
+	}
+
+	return ordered, nil
+}
+
+// Blockers resolves the story's blocked_by references to Tracker story IDs,
+// using created to look up the IDs of stories from the same manifest.
+func (s ManifestStory) Blockers(created map[string]int) ([]int, error) {
+	var ids []int
+	for _, ref := range s.BlockedBy {
+		if id, found := created[ref]; found {
+			ids = append(ids, id)
+			continue
+		}
+
+		id, err := storyIDReference(ref)
+		if err != nil {
+			return nil, fmt.Errorf("unknown blocker reference: %s", ref)
+		}
+
+		ids = append(ids, id)
+	}
+
+	return ids, nil
+}
+
+func storyIDReference(ref string) (int, error) {
+	return strconv.Atoi(strings.TrimPrefix(ref, "#"))
+}
@@ -0,0 +1,79 @@
+package out_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/cjcjameson/tracker-story-resource/out"
+)
+
+var _ = Describe("Ordering a manifest", func() {
+	names := func(stories []out.ManifestStory) []string {
+		var names []string
+		for _, story := range stories {
+			names = append(names, story.Name)
+		}
+		return names
+	}
+
+	It("puts blocking stories before the stories they block", func() {
+		manifest := out.Manifest{
+			Stories: []out.ManifestStory{
+				{ID: "deploy", Name: "deploy", BlockedBy: []string{"build", "#1234"}},
+				{ID: "build", Name: "build", BlockedBy: []string{"design"}},
+				{ID: "design", Name: "design"},
+				{Name: "unrelated"},
+			},
+		}
+
+		ordered, err := manifest.Ordered()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(names(ordered)).To(Equal([]string{"design", "build", "deploy", "unrelated"}))
+	})
+
+	It("reports cycles", func() {
+		manifest := out.Manifest{
+			Stories: []out.ManifestStory{
+				{ID: "a", BlockedBy: []string{"b"}},
+				{ID: "b", BlockedBy: []string{"c"}},
+				{ID: "c", BlockedBy: []string{"a"}},
+			},
+		}
+
+		_, err := manifest.Ordered()
+		Expect(err).To(MatchError("blocker cycle: a -> b -> c -> a"))
+	})
+
+	It("reports references to stories that are not in the manifest", func() {
+		manifest := out.Manifest{
+			Stories: []out.ManifestStory{
+				{ID: "a", BlockedBy: []string{"nope"}},
+			},
+		}
+
+		_, err := manifest.Ordered()
+		Expect(err).To(MatchError("unknown blocker reference: nope"))
+	})
+
+	It("reports duplicate ids", func() {
+		manifest := out.Manifest{
+			Stories: []out.ManifestStory{
+				{ID: "a"},
+				{ID: "a"},
+			},
+		}
+
+		_, err := manifest.Ordered()
+		Expect(err).To(MatchError("duplicate story id: a"))
+	})
+})
+
+var _ = Describe("Resolving blockers", func() {
+	It("uses the IDs of created stories and existing story IDs", func() {
+		story := out.ManifestStory{BlockedBy: []string{"build", "#1234", "5678"}}
+
+		ids, err := story.Blockers(map[string]int{"build": 42})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ids).To(Equal([]int{42, 1234, 5678}))
+	})
+})
@@ -1,90 +1,38 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
-	"errors"
 	"fmt"
-	"io/ioutil"
 	"os"
-	"path/filepath"
-	"strconv"
-	"time"
 
-	"github.com/XenoPhex/go-tracker"
 	"github.com/cjcjameson/tracker-story-resource"
 	"github.com/cjcjameson/tracker-story-resource/out"
 	"github.com/mitchellh/colorstring"
 )
 
-func buildRequest() out.OutRequest {
-	var request out.OutRequest
-
-	err := json.NewDecoder(os.Stdin).Decode(&request)
-	if err != nil {
-		fatal("reading request", err)
-	}
-
-	return request
-}
-
 func main() {
 	if len(os.Args) < 2 {
-		sayf("usage: %s <sources directory>\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "usage: %s <sources directory>\n", os.Args[0])
 		os.Exit(1)
 	}
 
-	sources := os.Args[1]
-	request := buildRequest()
-
-	trackerURL := request.Source.TrackerURL
-	if trackerURL == "" {
-		trackerURL = "https://www.pivotaltracker.com"
+	var request out.OutRequest
+	if err := json.NewDecoder(os.Stdin).Decode(&request); err != nil {
+		fatal(fmt.Errorf("reading request: %s", err))
 	}
 
-	token := request.Source.Token
-	projectID, err := strconv.Atoi(request.Source.ProjectID)
+	response, err := out.Run(context.Background(), request, os.Args[1], resource.Env{Log: os.Stderr})
 	if err != nil {
-		fatal("converting the project ID to an integer", err)
+		fatal(err)
 	}
 
-	contentPath := request.Params.ContentPath
-
-	var contents []byte
-	if contentPath != "" {
-		contents, err = ioutil.ReadFile(filepath.Join(sources, contentPath))
-		if err != nil {
-			fatal("reading content file", err)
-		}
-	} else {
-		fatal("error", errors.New("no content file specified"))
-	}
-
-	tracker.DefaultURL = trackerURL
-	client := tracker.NewClient(token).InProject(projectID)
-	story := tracker.Story{
-		Name:  string(contents),
-		Type:  tracker.StoryTypeChore,
-		State: tracker.StoryStateUnscheduled,
+	if err := json.NewEncoder(os.Stdout).Encode(response); err != nil {
+		fatal(fmt.Errorf("writing response: %s", err))
 	}
-	story, err = client.CreateStory(story)
-	sayf("Story created with ID: %d Name: %s", story.ID, story.Name)
-
-	outputResponse()
-}
-
-func outputResponse() {
-	json.NewEncoder(os.Stdout).Encode(out.OutResponse{
-		Version: resource.Version{
-			Time: time.Now(),
-		},
-	})
-}
-
-func sayf(message string, args ...interface{}) {
-	fmt.Fprintf(os.Stderr, message, args...)
 }
 
-func fatal(doing string, err error) {
-	sayf(colorstring.Color("[red]error %s: %s\n"), doing, err)
+func fatal(err error) {
+	fmt.Fprintf(os.Stderr, colorstring.Color("[red]error %s\n"), err)
 	os.Exit(1)
 }
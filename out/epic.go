@@ -0,0 +1,56 @@
+package out
+
+import (
+	"github.com/XenoPhex/go-tracker"
+	"github.com/cjcjameson/tracker-story-resource"
+)
+
+// EpicLabels finds the label that ties stories to each named epic, creating
+// the epic (and with it the label) with the writer when the project does not
+// have it yet.
+type EpicLabels struct {
+	client resource.Reader
+	writer Writer
+	epics  map[string]tracker.Epic
+}
+
+func NewEpicLabels(client resource.Reader, writer Writer) *EpicLabels {
+	return &EpicLabels{
+		client: client,
+		writer: writer,
+	}
+}
+
+func (e *EpicLabels) Label(name string) (tracker.Label, error) {
+	if e.epics == nil {
+		epics, err := e.client.Epics()
+		if err != nil {
+			return tracker.Label{}, err
+		}
+
+		e.epics = map[string]tracker.Epic{}
+		for _, epic := range epics {
+			e.epics[epic.Name] = epic
+		}
+	}
+
+	epic, found := e.epics[name]
+	if !found {
+		var err error
+		epic, err = e.writer.CreateEpic(tracker.Epic{
+			Name:  name,
+			Label: &tracker.Label{Name: name},
+		})
+		if err != nil {
+			return tracker.Label{}, err
+		}
+
+		e.epics[name] = epic
+	}
+
+	if epic.Label == nil {
+		return tracker.Label{Name: name}, nil
+	}
+
+	return tracker.Label{Name: epic.Label.Name}, nil
+}
@@ -0,0 +1,160 @@
+package out
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/XenoPhex/go-tracker"
+	"github.com/cjcjameson/tracker-story-resource"
+)
+
+// Finding is a problem reported by a tool, such as a failing test, that
+// should be tracked as a story.
+type Finding struct {
+	Fingerprint string
+	Name        string
+	Description string
+	Type        tracker.StoryType
+	Labels      []string
+	Tasks       []string
+
+	// Standing findings, like vulnerabilities, are reported by every scan
+	// until they are fixed, so reporting them again is not a new occurrence.
+	Standing bool
+
+	// Replaces lists the fingerprints of findings this one takes over, such
+	// as the locations of a rule that is now filed as one story. Their open
+	// stories are closed.
+	Replaces []string
+}
+
+// Report is what a tool found. Passed lists the fingerprints of the checks,
+// such as tests, that the tool saw succeed; Resolved lists findings the tool
+// says have gone away.
+type Report struct {
+	Findings []Finding
+	Passed   []string
+	Resolved []Resolution
+}
+
+func (p Params) ParseReport(contents []byte) (Report, error) {
+	switch p.Format {
+	case "junit":
+		return ParseJUnit(contents)
+	case "go-test":
+		return ParseGoTest(contents)
+	case "govulncheck":
+		return ParseGovulncheck(contents)
+	case "trivy":
+		return ParseTrivy(contents)
+	case "sarif":
+		return ParseSARIF(contents, p.SARIF)
+	case "alertmanager":
+		return ParseAlertmanager(contents)
+	}
+
+	return Report{}, fmt.Errorf("unknown content format: %s", p.Format)
+}
+
+func (f Finding) Story() tracker.Story {
+	storyType := f.Type
+	if storyType == "" {
+		storyType = tracker.StoryTypeBug
+	}
+
+	story := tracker.Story{
+		Name:        f.Name,
+		Description: WithMarker(f.Description, Marker{Fingerprint: f.Fingerprint}),
+		Type:        storyType,
+		State:       tracker.StoryStateUnscheduled,
+	}
+
+	for _, name := range f.Labels {
+		story.Labels = append(story.Labels, tracker.Label{Name: name})
+	}
+
+	for _, description := range f.Tasks {
+		story.Tasks = append(story.Tasks, tracker.Task{Description: description})
+	}
+
+	return story
+}
+
+// Marker is kept in a hidden comment at the end of the description of the
+// stories filed for findings so that later runs can recognise them.
+type Marker struct {
+	Fingerprint string `json:"fingerprint"`
+
+	// Count is how many times the finding occurred. Occurrences only keeps
+	// the times the flaky check still needs.
+	Count       int         `json:"count,omitempty"`
+	Occurrences []time.Time `json:"occurrences,omitempty"`
+	PassedAt    *time.Time  `json:"passed_at,omitempty"`
+}
+
+// Total is how many times the finding occurred. Markers written before the
+// count was kept have every occurrence instead.
+func (m Marker) Total() int {
+	if m.Count == 0 {
+		return len(m.Occurrences)
+	}
+
+	return m.Count
+}
+
+const markerPrefix = "<!-- tracker-story-resource "
+
+var markerPattern = regexp.MustCompile(`(?s)\n*<!-- tracker-story-resource (\{.*?\}) -->\s*$`)
+
+func WithMarker(description string, marker Marker) string {
+	description = markerPattern.ReplaceAllString(description, "")
+
+	contents, _ := json.Marshal(marker)
+	return strings.TrimRight(description, "\n") + "\n\n" + markerPrefix + string(contents) + " -->"
+}
+
+func ParseMarker(description string) (Marker, bool) {
+	match := markerPattern.FindStringSubmatch(description)
+	if match == nil {
+		return Marker{}, false
+	}
+
+	var marker Marker
+	if err := json.Unmarshal([]byte(match[1]), &marker); err != nil {
+		return Marker{}, false
+	}
+
+	return marker, true
+}
+
+// OpenFindings fetches the unaccepted stories previously filed for findings,
+// keyed by fingerprint.
+func OpenFindings(client resource.Reader) (map[string]tracker.Story, error) {
+	stories, err := resource.AllStories(client, tracker.StoriesQuery{
+		Filter: "-state:accepted",
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	open := map[string]tracker.Story{}
+	for _, story := range stories {
+		if marker, found := ParseMarker(story.Description); found {
+			open[marker.Fingerprint] = story
+		}
+	}
+
+	return open, nil
+}
+
+func trimLines(text string, max int) string {
+	lines := strings.Split(strings.TrimSpace(text), "\n")
+	if len(lines) <= max {
+		return strings.Join(lines, "\n")
+	}
+
+	return strings.Join(lines[:max], "\n") + fmt.Sprintf("\n... (%d more lines)", len(lines)-max)
+}
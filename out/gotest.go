@@ -0,0 +1,167 @@
+package out
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+type goTestEvent struct {
+	Action  string `json:"Action"`
+	Package string `json:"Package"`
+	Test    string `json:"Test"`
+	Output  string `json:"Output"`
+}
+
+type goTestResult struct {
+	pkg    string
+	test   string
+	action string
+	output bytes.Buffer
+}
+
+// ParseGoTest files a bug for every failing test in a `go test -json` event
+// stream. Tests that never finished in a failed package are taken to have
+// panicked, and a failed package with no failed tests, such as one that did
+// not build, is filed on its own.
+func ParseGoTest(contents []byte) (Report, error) {
+	results := map[string]*goTestResult{}
+	var order []string
+
+	scanner := bufio.NewScanner(bytes.NewReader(contents))
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+
+	for line := 1; scanner.Scan(); line++ {
+		text := bytes.TrimSpace(scanner.Bytes())
+		if len(text) == 0 {
+			continue
+		}
+
+		var event goTestEvent
+		if err := json.Unmarshal(text, &event); err != nil {
+			return Report{}, fmt.Errorf("invalid go test event on line %d: %s", line, err)
+		}
+
+		key := event.Package + "\x00" + event.Test
+		result, found := results[key]
+		if !found {
+			result = &goTestResult{pkg: event.Package, test: event.Test}
+			results[key] = result
+			order = append(order, key)
+		}
+
+		switch event.Action {
+		case "output":
+			result.output.WriteString(event.Output)
+		case "pass", "fail", "skip":
+			result.action = event.Action
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return Report{}, err
+	}
+
+	failedPackages := map[string]bool{}
+	for _, key := range order {
+		result := results[key]
+		if result.test == "" && result.action == "fail" {
+			failedPackages[result.pkg] = true
+		}
+	}
+
+	failed := map[string]bool{}
+	for _, key := range order {
+		result := results[key]
+		if result.test == "" {
+			continue
+		}
+
+		if result.action == "fail" || (result.action == "" && failedPackages[result.pkg]) {
+			failed[key] = true
+		}
+	}
+
+	var report Report
+	filedInPackage := map[string]bool{}
+
+	for _, key := range order {
+		result := results[key]
+		if result.action != "pass" {
+			continue
+		}
+
+		name := result.pkg
+		if result.test != "" {
+			name += "." + result.test
+		}
+
+		report.Passed = append(report.Passed, "gotest:"+name)
+	}
+
+	for _, key := range order {
+		if !failed[key] {
+			continue
+		}
+
+		result := results[key]
+		if hasFailedSubtest(result, failed) {
+			continue
+		}
+
+		filedInPackage[result.pkg] = true
+
+		description := strings.TrimSpace(result.output.String())
+		if result.action == "" {
+			description = "The test did not finish; it probably panicked.\n\n" + description
+		}
+
+		packageOutput := ""
+		if pkg, found := results[result.pkg+"\x00"]; found {
+			packageOutput = pkg.output.String()
+		}
+
+		report.Findings = append(report.Findings, goTestFinding(result.pkg+"."+result.test, description, packageOutput))
+	}
+
+	for _, key := range order {
+		result := results[key]
+		if result.test != "" || !failedPackages[result.pkg] || filedInPackage[result.pkg] {
+			continue
+		}
+
+		report.Findings = append(report.Findings, goTestFinding(result.pkg, "", result.output.String()))
+	}
+
+	return report, nil
+}
+
+func hasFailedSubtest(result *goTestResult, failed map[string]bool) bool {
+	prefix := result.pkg + "\x00" + result.test + "/"
+	for key := range failed {
+		if strings.HasPrefix(key, prefix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func goTestFinding(name string, testOutput string, packageOutput string) Finding {
+	output := testOutput
+	if strings.Contains(packageOutput, "panic:") && !strings.Contains(output, "panic:") {
+		output = strings.TrimSpace(output + "\n" + packageOutput)
+	}
+
+	if output == "" {
+		output = packageOutput
+	}
+
+	return Finding{
+		Fingerprint: "gotest:" + name,
+		Name:        name,
+		Description: failureDescription("", output),
+	}
+}
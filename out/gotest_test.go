@@ -0,0 +1,65 @@
+package out_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/cjcjameson/tracker-story-resource/out"
+)
+
+var _ = Describe("Parsing go test -json output", func() {
+	var (
+		report   out.Report
+		findings []out.Finding
+	)
+
+	BeforeEach(func() {
+		var err error
+		report, err = out.ParseGoTest([]byte(Fixture("go-test.json")))
+		Expect(err).NotTo(HaveOccurred())
+
+		findings = report.Findings
+	})
+
+	names := func() []string {
+		var names []string
+		for _, finding := range findings {
+			names = append(names, finding.Name)
+		}
+		return names
+	}
+
+	It("files the failing leaf tests, panics and broken packages", func() {
+		Expect(names()).To(Equal([]string{
+			"example.com/deathstar/beam.TestRelease/falcon",
+			"example.com/deathstar/port.TestShield",
+			"example.com/deathstar/reactor",
+		}))
+	})
+
+	It("fingerprints findings by package and test", func() {
+		Expect(findings[0].Fingerprint).To(Equal("gotest:example.com/deathstar/beam.TestRelease/falcon"))
+	})
+
+	It("describes failures with their output", func() {
+		Expect(findings[0].Description).To(ContainSubstring("beam_test.go:12: ship still held"))
+	})
+
+	It("includes the panic for tests that did not finish", func() {
+		Expect(findings[1].Description).To(ContainSubstring("probably panicked"))
+		Expect(findings[1].Description).To(ContainSubstring("panic: runtime error"))
+	})
+
+	It("includes the package output for packages that failed on their own", func() {
+		Expect(findings[2].Description).To(ContainSubstring("[build failed]"))
+	})
+
+	It("lists the tests and packages that passed", func() {
+		Expect(report.Passed).To(Equal([]string{"gotest:example.com/deathstar/beam.TestHold"}))
+	})
+
+	It("rejects lines that are not events", func() {
+		_, err := out.ParseGoTest([]byte("ok  \texample.com/deathstar\n"))
+		Expect(err).To(MatchError(ContainSubstring("invalid go test event on line 1")))
+	})
+})
@@ -0,0 +1,121 @@
+package out
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"regexp"
+	"strconv"
+
+	"github.com/XenoPhex/go-tracker"
+)
+
+// Import recreates the stories of an export, such as the stories.json the in
+// parameter writes, in the project. Each imported story carries a marker
+// naming the story it was imported for, so that an import which failed partway
+// can be run again to finish it: the project is the record of what was
+// imported, as a put cannot keep files between builds. The mapping file, if
+// asked for, is only a report of the ID each story was given, written once
+// the import succeeds; it is not read back.
+type Import struct {
+	File    string `json:"file"`
+	Mapping string `json:"mapping"`
+}
+
+// ImportFields asks Tracker for the comments and blockers of the stories
+// already imported, to copy only the ones they are missing.
+const ImportFields = ":default,comments,blockers"
+
+// ImportFingerprint identifies the story imported for an exported one.
+func ImportFingerprint(exported tracker.Story) string {
+	return fmt.Sprintf("import:%d:%d", exported.ProjectID, exported.ID)
+}
+
+// ImportMapping maps the IDs of exported stories to the stories imported for
+// them.
+type ImportMapping struct {
+	Stories map[int]*ImportedStory `json:"stories"`
+}
+
+type ImportedStory struct {
+	ID int `json:"id"`
+}
+
+func ReadExport(path string) ([]tracker.Story, error) {
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var stories []tracker.Story
+	err = json.Unmarshal(contents, &stories)
+	return stories, err
+}
+
+func ReadImportMapping(path string) (ImportMapping, error) {
+	var mapping ImportMapping
+
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		return mapping, err
+	}
+
+	err = json.Unmarshal(contents, &mapping)
+	return mapping, err
+}
+
+func (m ImportMapping) Write(path string) error {
+	contents, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(path, append(contents, '\n'), 0644)
+}
+
+var storyReferencePattern = regexp.MustCompile(`(#|/story/show/)(\d+)\b`)
+
+// Remap points references to exported stories, like #123 or links to them,
+// at the stories imported for them.
+func (m ImportMapping) Remap(text string) string {
+	return storyReferencePattern.ReplaceAllStringFunc(text, func(reference string) string {
+		match := storyReferencePattern.FindStringSubmatch(reference)
+
+		id, _ := strconv.Atoi(match[2])
+		if imported, found := m.Stories[id]; found {
+			return match[1] + strconv.Itoa(imported.ID)
+		}
+
+		return reference
+	})
+}
+
+// ImportStory is the story to create for an exported one, marked with its
+// fingerprint. A marker the exported description already has, such as that of
+// an earlier import, is replaced, as a story carries one. The estimate is kept
+// if the project takes it, as for a mirror. Comments and blockers are added
+// separately once every story exists, and owners are left out as they may not
+// be members of the project.
+func ImportStory(exported tracker.Story, project tracker.Project) tracker.Story {
+	story := tracker.Story{
+		Name:        exported.Name,
+		Description: WithMarker(exported.Description, Marker{Fingerprint: ImportFingerprint(exported)}),
+		Type:        exported.Type,
+		State:       exported.State,
+	}
+
+	story = withEstimate(story, exported.Estimate, project)
+
+	for _, label := range exported.Labels {
+		story.Labels = append(story.Labels, tracker.Label{Name: label.Name})
+	}
+
+	for _, task := range exported.Tasks {
+		story.Tasks = append(story.Tasks, tracker.Task{
+			Description: task.Description,
+			Complete:    task.Complete,
+		})
+	}
+
+	return story
+}
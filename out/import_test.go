@@ -0,0 +1,199 @@
+package out_test
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/XenoPhex/go-tracker"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+
+	"github.com/cjcjameson/tracker-story-resource"
+	"github.com/cjcjameson/tracker-story-resource/in"
+	"github.com/cjcjameson/tracker-story-resource/out"
+	"github.com/cjcjameson/tracker-story-resource/trackerfake"
+)
+
+var _ = Describe("Import", func() {
+	var (
+		source, target *trackerfake.Server
+		env            resource.Env
+		log            *gbytes.Buffer
+		sources        string
+		request        out.OutRequest
+
+		port, shield, vent tracker.Story
+	)
+
+	BeforeEach(func() {
+		source = trackerfake.New("abc", 1111)
+		target = trackerfake.New("abc", 2222)
+
+		sourceClient, err := resource.NewProjectClient(source.Source())
+		Expect(err).NotTo(HaveOccurred())
+
+		sources, err = ioutil.TempDir("", "out-import")
+		Expect(err).NotTo(HaveOccurred())
+
+		port = source.AddStory(tracker.Story{
+			Name:   "Cover the exhaust port",
+			Type:   tracker.StoryTypeBug,
+			State:  tracker.StoryStateStarted,
+			Labels: []tracker.Label{{Name: "reactor"}},
+			Tasks:  []tracker.Task{{Description: "Find the port", Complete: true}},
+		})
+		shield = source.AddStory(tracker.Story{
+			Name:        "Raise the shield",
+			Description: fmt.Sprintf("Only once #%d is done.", port.ID),
+		})
+		vent = source.AddStory(tracker.Story{Name: "Vent the reactor"})
+
+		_, err = sourceClient.CreateComment(port.ID, tracker.Comment{Text: fmt.Sprintf("See %s", shield.URL)})
+		Expect(err).NotTo(HaveOccurred())
+
+		_, err = sourceClient.CreateBlocker(shield.ID, tracker.Blocker{Description: fmt.Sprintf("#%d", port.ID)})
+		Expect(err).NotTo(HaveOccurred())
+
+		_, err = in.Run(context.Background(), in.InRequest{
+			Params: in.Params{Export: &in.ExportParams{}},
+		}, sources, resource.Env{Client: sourceClient})
+		Expect(err).NotTo(HaveOccurred())
+
+		targetClient, err := resource.NewProjectClient(target.Source())
+		Expect(err).NotTo(HaveOccurred())
+
+		log = gbytes.NewBuffer()
+		env = resource.Env{Client: targetClient, Log: log}
+
+		request = out.OutRequest{
+			Params: out.Params{Import: &out.Import{File: "stories.json"}},
+		}
+	})
+
+	AfterEach(func() {
+		source.Close()
+		target.Close()
+		os.RemoveAll(sources)
+	})
+
+	storyNamed := func(name string) tracker.Story {
+		for _, story := range target.Stories() {
+			if story.Name == name {
+				return story
+			}
+		}
+
+		Fail("no story named " + name)
+		return tracker.Story{}
+	}
+
+	It("recreates the stories, remapping references to the new IDs", func() {
+		response, err := out.Run(context.Background(), request, sources, env)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(response.Metadata).To(Equal([]resource.MetadataPair{
+			{Name: "imported_stories", Value: "3"},
+			{Name: "imported_comments", Value: "1"},
+			{Name: "imported_blockers", Value: "1"},
+		}))
+
+		Expect(target.Stories()).To(HaveLen(3))
+		newPort := storyNamed("Cover the exhaust port")
+		newShield := storyNamed("Raise the shield")
+
+		Expect(newPort.Type).To(BeEquivalentTo(tracker.StoryTypeBug))
+		Expect(newPort.State).To(BeEquivalentTo(tracker.StoryStateStarted))
+		Expect(resource.HasLabel(newPort, "reactor")).To(BeTrue())
+		Expect(newPort.Tasks).To(HaveLen(1))
+		Expect(newPort.Tasks[0].Complete).To(BeTrue())
+
+		Expect(newShield.Description).To(HavePrefix(fmt.Sprintf("Only once #%d is done.\n\n<!-- tracker-story-resource ", newPort.ID)))
+		marker, found := out.ParseMarker(newShield.Description)
+		Expect(found).To(BeTrue())
+		Expect(marker.Fingerprint).To(Equal(fmt.Sprintf("import:1111:%d", shield.ID)))
+		Expect(target.Comments(newPort.ID)[0].Text).To(Equal(fmt.Sprintf("See %s", newShield.URL)))
+		Expect(target.Blockers(newShield.ID)[0].Description).To(Equal(fmt.Sprintf("#%d", newPort.ID)))
+
+		Expect(log).To(gbytes.Say("Story %d imported as %d", port.ID, newPort.ID))
+		Expect(log).To(gbytes.Say("Import: 3 stories, 1 comments, 1 blockers"))
+	})
+
+	It("resumes where a failed import stopped", func() {
+		target.Inject(trackerfake.Fault{Method: "POST", Path: "/stories", After: 2, Times: 1, Status: 500})
+
+		_, err := out.Run(context.Background(), request, sources, env)
+		Expect(err).To(MatchError("creating story: request failed (500)"))
+		Expect(target.Stories()).To(HaveLen(2))
+
+		response, err := out.Run(context.Background(), request, sources, env)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(response.Metadata[0]).To(Equal(resource.MetadataPair{Name: "imported_stories", Value: "1"}))
+
+		Expect(target.Stories()).To(HaveLen(3))
+		Expect(target.Comments(storyNamed("Cover the exhaust port").ID)).To(HaveLen(1))
+
+		response, err = out.Run(context.Background(), request, sources, env)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(target.Stories()).To(HaveLen(3))
+		Expect(response.Metadata).To(ConsistOf(
+			resource.MetadataPair{Name: "imported_stories", Value: "0"},
+			resource.MetadataPair{Name: "imported_comments", Value: "0"},
+			resource.MetadataPair{Name: "imported_blockers", Value: "0"},
+		))
+	})
+
+	It("does not duplicate a story created by a put that then failed", func() {
+		target.Inject(trackerfake.Fault{Method: "POST", Path: "/stories", After: 1, Times: 1, Truncate: true})
+
+		_, err := out.Run(context.Background(), request, sources, env)
+		Expect(err).To(MatchError(HavePrefix("creating story: ")))
+		Expect(target.Stories()).To(HaveLen(2))
+
+		response, err := out.Run(context.Background(), request, sources, env)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(response.Metadata[0]).To(Equal(resource.MetadataPair{Name: "imported_stories", Value: "1"}))
+		Expect(target.Stories()).To(HaveLen(3))
+	})
+
+	It("keeps only the estimates the project takes, leaving features without one unstarted", func() {
+		five, two := 5.0, 2.0
+		source.AddStory(tracker.Story{Name: "Shard the database", Type: tracker.StoryTypeFeature, State: tracker.StoryStateDelivered, Estimate: &five})
+		source.AddStory(tracker.Story{Name: "Tune the pool", Type: tracker.StoryTypeFeature, State: tracker.StoryStateStarted, Estimate: &two})
+		source.AddStory(tracker.Story{Name: "Cache the catalog", Type: tracker.StoryTypeFeature, State: tracker.StoryStateFinished})
+
+		sourceClient, err := resource.NewProjectClient(source.Source())
+		Expect(err).NotTo(HaveOccurred())
+
+		_, err = in.Run(context.Background(), in.InRequest{
+			Params: in.Params{Export: &in.ExportParams{}},
+		}, sources, resource.Env{Client: sourceClient})
+		Expect(err).NotTo(HaveOccurred())
+
+		_, err = out.Run(context.Background(), request, sources, env)
+		Expect(err).NotTo(HaveOccurred())
+
+		sharded := storyNamed("Shard the database")
+		Expect(sharded.Estimate).To(BeNil())
+		Expect(sharded.State).To(BeEquivalentTo(tracker.StoryStateUnstarted))
+
+		tuned := storyNamed("Tune the pool")
+		Expect(*tuned.Estimate).To(Equal(2.0))
+		Expect(tuned.State).To(BeEquivalentTo(tracker.StoryStateStarted))
+
+		Expect(storyNamed("Cache the catalog").State).To(BeEquivalentTo(tracker.StoryStateUnstarted))
+	})
+
+	It("writes the mapping where asked", func() {
+		request.Params.Import.Mapping = "split.json"
+
+		_, err := out.Run(context.Background(), request, sources, env)
+		Expect(err).NotTo(HaveOccurred())
+
+		mapping, err := out.ReadImportMapping(filepath.Join(sources, "split.json"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(mapping.Stories[vent.ID]).To(Equal(&out.ImportedStory{ID: storyNamed("Vent the reactor").ID}))
+	})
+})
@@ -0,0 +1,116 @@
+package out
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strings"
+)
+
+const stackLines = 30
+
+type junitSuites struct {
+	Suites []junitSuite `xml:"testsuite"`
+}
+
+type junitSuite struct {
+	Name   string          `xml:"name,attr"`
+	Suites []junitSuite    `xml:"testsuite"`
+	Cases  []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Failure   *junitFailure `xml:"failure"`
+	Error     *junitFailure `xml:"error"`
+	Skipped   *struct{}     `xml:"skipped"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Body    string `xml:",chardata"`
+}
+
+// ParseJUnit files a bug for every failing or erroring test case in a JUnit
+// XML report, whether its root is <testsuites> or a single <testsuite>.
+func ParseJUnit(contents []byte) (Report, error) {
+	var root struct {
+		XMLName xml.Name
+	}
+	if err := xml.Unmarshal(contents, &root); err != nil {
+		return Report{}, fmt.Errorf("invalid junit report: %s", err)
+	}
+
+	var suites []junitSuite
+	switch root.XMLName.Local {
+	case "testsuites":
+		var report junitSuites
+		if err := xml.Unmarshal(contents, &report); err != nil {
+			return Report{}, fmt.Errorf("invalid junit report: %s", err)
+		}
+		suites = report.Suites
+	case "testsuite":
+		var suite junitSuite
+		if err := xml.Unmarshal(contents, &suite); err != nil {
+			return Report{}, fmt.Errorf("invalid junit report: %s", err)
+		}
+		suites = []junitSuite{suite}
+	default:
+		return Report{}, fmt.Errorf("invalid junit report: unexpected <%s>", root.XMLName.Local)
+	}
+
+	var report Report
+	for _, suite := range suites {
+		addJUnitSuite(&report, suite)
+	}
+
+	return report, nil
+}
+
+func addJUnitSuite(report *Report, suite junitSuite) {
+	for _, nested := range suite.Suites {
+		addJUnitSuite(report, nested)
+	}
+
+	for _, testCase := range suite.Cases {
+		suiteName := suite.Name
+		if suiteName == "" {
+			suiteName = testCase.ClassName
+		}
+
+		name := suiteName + "/" + testCase.Name
+		fingerprint := "junit:" + name
+
+		failure := testCase.Failure
+		if failure == nil {
+			failure = testCase.Error
+		}
+
+		if failure == nil {
+			if testCase.Skipped == nil {
+				report.Passed = append(report.Passed, fingerprint)
+			}
+			continue
+		}
+
+		report.Findings = append(report.Findings, Finding{
+			Fingerprint: fingerprint,
+			Name:        name,
+			Description: failureDescription(failure.Message, failure.Body),
+		})
+	}
+}
+
+func failureDescription(message string, output string) string {
+	parts := []string{}
+	if message = strings.TrimSpace(message); message != "" {
+		parts = append(parts, message)
+	}
+
+	if output = strings.TrimSpace(output); output != "" {
+		parts = append(parts, "```\n"+trimLines(output, stackLines)+"\n```")
+	}
+
+	return strings.Join(parts, "\n\n")
+}
@@ -0,0 +1,66 @@
+package out_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/cjcjameson/tracker-story-resource/out"
+)
+
+var _ = Describe("Parsing JUnit reports", func() {
+	It("finds every failing and erroring test case", func() {
+		report, err := out.ParseJUnit([]byte(Fixture("junit.xml")))
+		Expect(err).NotTo(HaveOccurred())
+
+		findings := report.Findings
+
+		Expect(findings).To(HaveLen(2))
+
+		Expect(findings[0].Name).To(Equal("deathstar.TractorBeamTest/testReleasesShips"))
+		Expect(findings[0].Fingerprint).To(Equal("junit:deathstar.TractorBeamTest/testReleasesShips"))
+		Expect(findings[0].Description).To(HavePrefix("expected ship to be released\n\n```\nAssertionError"))
+
+		Expect(findings[1].Name).To(Equal("deathstar.TractorBeamTest/testPower"))
+		Expect(findings[1].Description).To(Equal("power coupling offline"))
+	})
+
+	It("accepts a single test suite as the root", func() {
+		report, err := out.ParseJUnit([]byte(`<testsuite name="suite"><testcase name="test"><failure message="boom"/></testcase></testsuite>`))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(report.Findings).To(HaveLen(1))
+		Expect(report.Findings[0].Name).To(Equal("suite/test"))
+	})
+
+	It("lists the test cases that passed", func() {
+		report, err := out.ParseJUnit([]byte(Fixture("junit.xml")))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(report.Passed).To(Equal([]string{"junit:deathstar.TractorBeamTest/testHoldsShips"}))
+	})
+
+	It("rejects documents that are not JUnit reports", func() {
+		_, err := out.ParseJUnit([]byte(`<html></html>`))
+		Expect(err).To(MatchError("invalid junit report: unexpected <html>"))
+	})
+})
+
+var _ = Describe("Finding markers", func() {
+	It("round-trips through a story description", func() {
+		description := out.WithMarker("it broke", out.Marker{Fingerprint: "junit:suite/test"})
+		Expect(description).To(Equal("it broke\n\n<!-- tracker-story-resource {\"fingerprint\":\"junit:suite/test\"} -->"))
+
+		marker, found := out.ParseMarker(description)
+		Expect(found).To(BeTrue())
+		Expect(marker.Fingerprint).To(Equal("junit:suite/test"))
+	})
+
+	It("replaces an existing marker", func() {
+		description := out.WithMarker("it broke", out.Marker{Fingerprint: "a"})
+		description = out.WithMarker(description, out.Marker{Fingerprint: "b"})
+		Expect(description).To(Equal("it broke\n\n<!-- tracker-story-resource {\"fingerprint\":\"b\"} -->"))
+	})
+
+	It("is not found in ordinary descriptions", func() {
+		_, found := out.ParseMarker("just a story")
+		Expect(found).To(BeFalse())
+	})
+})
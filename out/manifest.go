@@ -0,0 +1,67 @@
+package out
+
+import (
+	"fmt"
+	"io/ioutil"
+
+	"github.com/XenoPhex/go-tracker"
+	"gopkg.in/yaml.v2"
+)
+
+type Manifest struct {
+	Stories []ManifestStory `yaml:"stories"`
+}
+
+type ManifestStory struct {
+	ID        string   `yaml:"id"`
+	BlockedBy []string `yaml:"blocked_by"`
+
+	Name        string   `yaml:"name"`
+	Description string   `yaml:"description"`
+	Type        string   `yaml:"type"`
+	Labels      []string `yaml:"labels"`
+	Epic        string   `yaml:"epic"`
+}
+
+func ReadManifest(path string) (Manifest, error) {
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		return Manifest{}, err
+	}
+
+	var manifest Manifest
+	if err := yaml.Unmarshal(contents, &manifest); err != nil {
+		return Manifest{}, err
+	}
+
+	// Tracker rejects unknown types, and would do it halfway through the put
+	for _, story := range manifest.Stories {
+		switch story.Type {
+		case "", tracker.StoryTypeFeature, tracker.StoryTypeBug, tracker.StoryTypeChore, tracker.StoryTypeRelease:
+		default:
+			return Manifest{}, fmt.Errorf("unknown story type %q for story %q", story.Type, story.Name)
+		}
+	}
+
+	return manifest, nil
+}
+
+func (s ManifestStory) Story() tracker.Story {
+	storyType := tracker.StoryType(s.Type)
+	if storyType == "" {
+		storyType = tracker.StoryTypeChore
+	}
+
+	story := tracker.Story{
+		Name:        s.Name,
+		Description: s.Description,
+		Type:        storyType,
+		State:       tracker.StoryStateUnscheduled,
+	}
+
+	for _, name := range s.Labels {
+		story.Labels = append(story.Labels, tracker.Label{Name: name})
+	}
+
+	return story
+}
@@ -0,0 +1,223 @@
+package out
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/XenoPhex/go-tracker"
+	"github.com/cjcjameson/tracker-story-resource"
+)
+
+// Mirror copies the stories matching a label or filter in another project,
+// which may be on another Tracker, into the project. Each mirrored story
+// carries a key label naming the story it mirrors, by which later puts keep
+// its name, description, state, estimate and labels in step with the original.
+type Mirror struct {
+	From   resource.Source `json:"from"`
+	Label  string          `json:"label"`
+	Filter string          `json:"filter"`
+}
+
+// Validate checks the mirror against the put's source. A token is only
+// borrowed from the put for the same Tracker, as it is not valid on another.
+func (m Mirror) Validate(source resource.Source) error {
+	if m.From.ProjectID == "" {
+		return errors.New("mirror needs a project to mirror from")
+	}
+
+	if m.From.Token == "" && m.From.TrackerURL != "" && resource.TrackerURL(m.From) != resource.TrackerURL(source) {
+		return fmt.Errorf("mirror needs a token for %s, as it is not the put's Tracker", m.From.TrackerURL)
+	}
+
+	if m.Label == "" && m.Filter == "" {
+		return errors.New("mirror needs a label or filter")
+	}
+
+	return nil
+}
+
+// Origin is the source the stories are mirrored from. The token and Tracker
+// default to the put's own; Validate refuses to borrow the token for another
+// Tracker.
+func (m Mirror) Origin(source resource.Source) resource.Source {
+	origin := m.From
+	if origin.Token == "" {
+		origin.Token = source.Token
+	}
+
+	if origin.TrackerURL == "" {
+		origin.TrackerURL = source.TrackerURL
+	}
+
+	return origin
+}
+
+// keyPrefix names the Tracker as well as the project, as projects on two
+// Trackers can share an ID. The Tracker is the put's own unless From names
+// another, so Key and Mirrored expect From to be the Origin.
+func (m Mirror) keyPrefix() string {
+	return "mirror:" + resource.TrackerURL(m.From) + ":" + m.From.ProjectID + ":"
+}
+
+// Key is the label of the story mirroring the original.
+func (m Mirror) Key(original tracker.Story) string {
+	return m.keyPrefix() + strconv.Itoa(original.ID)
+}
+
+// Mirrored is the ID of the original a story mirrors, if it mirrors one from
+// this project.
+func (m Mirror) Mirrored(story tracker.Story) (int, bool) {
+	for _, label := range story.Labels {
+		if !strings.HasPrefix(label.Name, m.keyPrefix()) {
+			continue
+		}
+
+		if id, err := strconv.Atoi(strings.TrimPrefix(label.Name, m.keyPrefix())); err == nil {
+			return id, true
+		}
+	}
+
+	return 0, false
+}
+
+// Desired is the mirror of the original: its name, description with a link
+// back, state, type and labels, and the key label. The estimate is kept if the
+// project takes it; features that then have none stay unstarted, as Tracker
+// only lets estimated features be started.
+func (m Mirror) Desired(original tracker.Story, project tracker.Project) tracker.Story {
+	story := tracker.Story{
+		Name:        original.Name,
+		Description: strings.TrimSpace(original.Description + "\n\nMirrored from " + original.URL),
+		Type:        original.Type,
+		State:       original.State,
+	}
+
+	story = withEstimate(story, original.Estimate, project)
+
+	for _, label := range original.Labels {
+		story.Labels = append(story.Labels, tracker.Label{Name: label.Name})
+	}
+
+	story.Labels = append(story.Labels, tracker.Label{Name: m.Key(original)})
+
+	return story
+}
+
+// Estimable is whether the project takes the estimate for stories of the type:
+// features, or bugs and chores if it estimates those, on its point scale.
+func Estimable(project tracker.Project, storyType tracker.StoryType, estimate float64) bool {
+	if storyType != tracker.StoryTypeFeature && !project.BugsAndChoresAreEstimatable {
+		return false
+	}
+
+	for _, point := range strings.Split(project.PointScale, ",") {
+		if value, err := strconv.ParseFloat(strings.TrimSpace(point), 64); err == nil && value == estimate {
+			return true
+		}
+	}
+
+	return false
+}
+
+// withEstimate gives the story the estimate if the project takes it. A feature
+// left without one stays unstarted, as Tracker only lets estimated features be
+// started.
+func withEstimate(story tracker.Story, estimate *float64, project tracker.Project) tracker.Story {
+	if estimate != nil && Estimable(project, story.Type, *estimate) {
+		story.Estimate = estimate
+	}
+
+	feature := story.Type == "" || story.Type == tracker.StoryTypeFeature
+	if feature && story.Estimate == nil && needsEstimate(story.State) {
+		story.State = tracker.StoryStateUnstarted
+	}
+
+	return story
+}
+
+func needsEstimate(state tracker.StoryState) bool {
+	switch state {
+	case tracker.StoryStateStarted, tracker.StoryStateFinished, tracker.StoryStateDelivered,
+		tracker.StoryStateAccepted, tracker.StoryStateRejected:
+		return true
+	}
+
+	return false
+}
+
+// MirrorDrift is the update that would bring a mirror in step with the
+// original, and the names of the fields it changes. Desired only keeps an
+// estimate the project takes, and a feature moving to a state that needs one
+// gets it in the same update, as Tracker checks the two together.
+func MirrorDrift(existing tracker.Story, desired tracker.Story) (tracker.Story, []string) {
+	update, fields := Drift(existing, desired)
+
+	if existing.State != desired.State {
+		update.State = desired.State
+		fields = append(fields, "current_state")
+	}
+
+	if desired.Estimate != nil && !sameEstimate(existing.Estimate, desired.Estimate) {
+		update.Estimate = desired.Estimate
+		fields = append(fields, "estimate")
+	}
+
+	return update, fields
+}
+
+func sameEstimate(a, b *float64) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+
+	return *a == *b
+}
+
+// MirrorLink is the comment left on the original once it is mirrored.
+func MirrorLink(mirror tracker.Story) string {
+	link := mirror.URL
+	if link == "" {
+		link = storyRef(mirror.ID)
+	}
+
+	return "Mirrored to " + link
+}
+
+// MirrorReport counts what a mirror did to the project's mirrored stories,
+// and lists the originals it could not mirror.
+type MirrorReport struct {
+	Created   int
+	Updated   int
+	Unchanged int
+	Failed    []MirrorFailure
+}
+
+// MirrorFailure is why an original could not be mirrored.
+type MirrorFailure struct {
+	OriginalID int
+	Err        error
+}
+
+func (r MirrorReport) String() string {
+	return fmt.Sprintf("Mirror: %d created, %d updated, %d unchanged", r.Created, r.Updated, r.Unchanged)
+}
+
+// Error lists the originals that could not be mirrored.
+func (r MirrorReport) Error() error {
+	lines := []string{fmt.Sprintf("%d stories could not be mirrored:", len(r.Failed))}
+	for _, failure := range r.Failed {
+		lines = append(lines, fmt.Sprintf("  #%d: %s", failure.OriginalID, failure.Err))
+	}
+
+	return errors.New(strings.Join(lines, "\n"))
+}
+
+func (r MirrorReport) Metadata() []resource.MetadataPair {
+	return []resource.MetadataPair{
+		{Name: "created", Value: fmt.Sprintf("%d", r.Created)},
+		{Name: "updated", Value: fmt.Sprintf("%d", r.Updated)},
+		{Name: "unchanged", Value: fmt.Sprintf("%d", r.Unchanged)},
+	}
+}
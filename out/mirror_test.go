@@ -0,0 +1,268 @@
+package out_test
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/XenoPhex/go-tracker"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+
+	"github.com/cjcjameson/tracker-story-resource"
+	"github.com/cjcjameson/tracker-story-resource/out"
+	"github.com/cjcjameson/tracker-story-resource/trackerfake"
+)
+
+var _ = Describe("Mirror", func() {
+	var (
+		product, platform *trackerfake.Server
+		env               resource.Env
+		log               *gbytes.Buffer
+		sources           string
+		request           out.OutRequest
+
+		upgrade tracker.Story
+	)
+
+	BeforeEach(func() {
+		product = trackerfake.New("product-token", 1111)
+		platform = trackerfake.New("platform-token", 2222)
+
+		client, err := resource.NewProjectClient(platform.Source())
+		Expect(err).NotTo(HaveOccurred())
+
+		log = gbytes.NewBuffer()
+		env = resource.Env{Client: client, Log: log}
+
+		sources, err = ioutil.TempDir("", "out-mirror")
+		Expect(err).NotTo(HaveOccurred())
+
+		upgrade = product.AddStory(tracker.Story{
+			Name:        "Upgrade the database",
+			Description: "Postgres 9 is out of support.",
+			Type:        tracker.StoryTypeChore,
+			State:       tracker.StoryStateStarted,
+			Labels:      []tracker.Label{{Name: "platform"}, {Name: "db"}},
+		})
+		product.AddStory(tracker.Story{Name: "Redesign the checkout"})
+
+		request = out.OutRequest{
+			Params: out.Params{Mirror: &out.Mirror{From: product.Source(), Label: "platform"}},
+		}
+	})
+
+	AfterEach(func() {
+		product.Close()
+		platform.Close()
+		os.RemoveAll(sources)
+	})
+
+	mirrorStories := func() out.OutResponse {
+		response, err := out.Run(context.Background(), request, sources, env)
+		Expect(err).NotTo(HaveOccurred())
+		return response
+	}
+
+	key := func(original tracker.Story) string {
+		return fmt.Sprintf("mirror:%s:1111:%d", product.URL(), original.ID)
+	}
+
+	metadata := func(created, updated, unchanged string) []resource.MetadataPair {
+		return []resource.MetadataPair{
+			{Name: "created", Value: created},
+			{Name: "updated", Value: updated},
+			{Name: "unchanged", Value: unchanged},
+		}
+	}
+
+	It("copies the matching stories and links back to their mirrors", func() {
+		Expect(mirrorStories().Metadata).To(Equal(metadata("1", "0", "0")))
+
+		stories := platform.Stories()
+		Expect(stories).To(HaveLen(1))
+
+		mirrored := stories[0]
+		Expect(mirrored.Name).To(Equal("Upgrade the database"))
+		Expect(mirrored.Description).To(Equal("Postgres 9 is out of support.\n\nMirrored from " + upgrade.URL))
+		Expect(mirrored.Type).To(BeEquivalentTo(tracker.StoryTypeChore))
+		Expect(mirrored.State).To(BeEquivalentTo(tracker.StoryStateStarted))
+		Expect(resource.HasLabel(mirrored, "db")).To(BeTrue())
+		Expect(resource.HasLabel(mirrored, key(upgrade))).To(BeTrue())
+
+		comments := product.Comments(upgrade.ID)
+		Expect(comments).To(HaveLen(1))
+		Expect(comments[0].Text).To(Equal("Mirrored to " + mirrored.URL))
+
+		Expect(log).To(gbytes.Say("Story %d mirrored as %d", upgrade.ID, mirrored.ID))
+		Expect(log).To(gbytes.Say("Mirror: 1 created, 0 updated, 0 unchanged"))
+	})
+
+	It("keeps mirrors in step with the originals", func() {
+		mirrorStories()
+		Expect(mirrorStories().Metadata).To(Equal(metadata("0", "0", "1")))
+
+		productClient, err := resource.NewProjectClient(product.Source())
+		Expect(err).NotTo(HaveOccurred())
+
+		_, err = productClient.UpdateStory(tracker.Story{
+			ID:     upgrade.ID,
+			Name:   "Upgrade the database to Postgres 16",
+			State:  tracker.StoryStateFinished,
+			Labels: []tracker.Label{{Name: "platform"}},
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(mirrorStories().Metadata).To(Equal(metadata("0", "1", "0")))
+		Expect(log).To(gbytes.Say(`Story \d+ updated: name, labels, current_state`))
+
+		mirrored := platform.Stories()[0]
+		Expect(mirrored.Name).To(Equal("Upgrade the database to Postgres 16"))
+		Expect(mirrored.State).To(BeEquivalentTo(tracker.StoryStateFinished))
+		Expect(resource.HasLabel(mirrored, "db")).To(BeFalse())
+
+		Expect(product.Comments(upgrade.ID)).To(HaveLen(1))
+	})
+
+	It("plans the mirrors and links without writing to either project", func() {
+		request.Params.DryRun = true
+
+		mirrorStories()
+		Expect(platform.Stories()).To(BeEmpty())
+		Expect(product.Comments(upgrade.ID)).To(BeEmpty())
+
+		Expect(log).To(gbytes.Say(`\+ create chore "Upgrade the database" \(started\)`))
+		Expect(log).To(gbytes.Say(`~ comment on #%d in project 1111\n    \+ Mirrored to new story 1`, upgrade.ID))
+	})
+
+	It("defaults the token and Tracker of the original project to the put's own", func() {
+		mirror := out.Mirror{From: resource.Source{ProjectID: "1111"}}
+
+		origin := mirror.Origin(resource.Source{Token: "abc", TrackerURL: "https://tracker.example.com", ProjectID: "2222"})
+		Expect(origin).To(Equal(resource.Source{Token: "abc", TrackerURL: "https://tracker.example.com", ProjectID: "1111"}))
+	})
+
+	Context("when the originals are estimated features", func() {
+		var small, large tracker.Story
+
+		BeforeEach(func() {
+			two, five := 2.0, 5.0
+			small = product.AddStory(tracker.Story{Name: "Tune the pool", Type: tracker.StoryTypeFeature, State: tracker.StoryStateStarted, Estimate: &two, Labels: []tracker.Label{{Name: "platform"}}})
+			large = product.AddStory(tracker.Story{Name: "Shard the database", Type: tracker.StoryTypeFeature, State: tracker.StoryStateDelivered, Estimate: &five, Labels: []tracker.Label{{Name: "platform"}}})
+		})
+
+		mirrorOf := func(original tracker.Story) tracker.Story {
+			for _, story := range platform.Stories() {
+				if resource.HasLabel(story, key(original)) {
+					return story
+				}
+			}
+
+			Fail("no mirror of " + original.Name)
+			return tracker.Story{}
+		}
+
+		It("keeps the estimates on the project's point scale, and leaves features without one unstarted", func() {
+			Expect(mirrorStories().Metadata).To(Equal(metadata("3", "0", "0")))
+
+			mirrored := mirrorOf(small)
+			Expect(*mirrored.Estimate).To(Equal(2.0))
+			Expect(mirrored.State).To(BeEquivalentTo(tracker.StoryStateStarted))
+
+			mirrored = mirrorOf(large)
+			Expect(mirrored.Estimate).To(BeNil())
+			Expect(mirrored.State).To(BeEquivalentTo(tracker.StoryStateUnstarted))
+
+			Expect(mirrorStories().Metadata).To(Equal(metadata("0", "0", "3")))
+		})
+
+		It("sends the estimate when a mirrored feature is estimated and started", func() {
+			idea := product.AddStory(tracker.Story{Name: "Cache the catalog", Type: tracker.StoryTypeFeature, State: tracker.StoryStateUnstarted, Labels: []tracker.Label{{Name: "platform"}}})
+			mirrorStories()
+			Expect(mirrorOf(idea).Estimate).To(BeNil())
+
+			productClient, err := resource.NewProjectClient(product.Source())
+			Expect(err).NotTo(HaveOccurred())
+
+			two := 2.0
+			_, err = productClient.UpdateStory(tracker.Story{ID: idea.ID, Estimate: &two})
+			Expect(err).NotTo(HaveOccurred())
+			_, err = productClient.UpdateStory(tracker.Story{ID: idea.ID, State: tracker.StoryStateStarted})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(mirrorStories().Metadata).To(Equal(metadata("0", "1", "3")))
+			Expect(log).To(gbytes.Say(`Story \d+ updated: current_state, estimate`))
+
+			mirrored := mirrorOf(idea)
+			Expect(*mirrored.Estimate).To(Equal(2.0))
+			Expect(mirrored.State).To(BeEquivalentTo(tracker.StoryStateStarted))
+		})
+
+		It("keeps the estimates the project's wider point scale has", func() {
+			platform.PointScale = "0,1,2,3,5,8"
+
+			mirrorStories()
+
+			mirrored := mirrorOf(large)
+			Expect(*mirrored.Estimate).To(Equal(5.0))
+			Expect(mirrored.State).To(BeEquivalentTo(tracker.StoryStateDelivered))
+		})
+	})
+
+	It("mirrors the other originals when Tracker refuses one", func() {
+		product.AddStory(tracker.Story{Name: "Rotate the keys", Type: tracker.StoryTypeChore, Labels: []tracker.Label{{Name: "platform"}}})
+		platform.Inject(trackerfake.Fault{Method: "POST", Path: "/stories", Times: 1, Status: 400})
+
+		_, err := out.Run(context.Background(), request, sources, env)
+		Expect(err).To(MatchError(fmt.Sprintf("1 stories could not be mirrored:\n  #%d: creating story: request failed (400)", upgrade.ID)))
+
+		Expect(platform.Stories()).To(HaveLen(1))
+		Expect(platform.Stories()[0].Name).To(Equal("Rotate the keys"))
+		Expect(log).To(gbytes.Say("Mirror: 1 created, 0 updated, 0 unchanged"))
+	})
+
+	It("reads the originals with the injected client", func() {
+		origin, err := resource.NewProjectClient(product.Source())
+		Expect(err).NotTo(HaveOccurred())
+
+		var asked resource.Source
+		env.Origin = func(source resource.Source) (resource.Client, error) {
+			asked = source
+			return origin, nil
+		}
+		request.Params.Mirror.From = resource.Source{ProjectID: "1111", TrackerURL: product.URL(), Token: "unused"}
+
+		Expect(mirrorStories().Metadata).To(Equal(metadata("1", "0", "0")))
+		Expect(asked.ProjectID).To(Equal("1111"))
+	})
+
+	It("tells apart projects on two Trackers that share an ID", func() {
+		mirror := out.Mirror{From: resource.Source{ProjectID: "1111", TrackerURL: "https://tracker.example.com"}}
+		other := out.Mirror{From: resource.Source{ProjectID: "1111"}}
+
+		story := tracker.Story{Labels: []tracker.Label{{Name: mirror.Key(upgrade)}}}
+		_, found := other.Mirrored(story)
+		Expect(found).To(BeFalse())
+
+		id, found := mirror.Mirrored(story)
+		Expect(found).To(BeTrue())
+		Expect(id).To(Equal(upgrade.ID))
+	})
+
+	It("needs a token to mirror from another Tracker", func() {
+		request.Params.Mirror.From.Token = ""
+
+		_, err := out.Run(context.Background(), request, sources, env)
+		Expect(err).To(MatchError(fmt.Sprintf("mirror needs a token for %s, as it is not the put's Tracker", product.URL())))
+		Expect(platform.Stories()).To(BeEmpty())
+	})
+
+	It("needs a label or filter", func() {
+		request.Params.Mirror.Label = ""
+
+		_, err := out.Run(context.Background(), request, sources, env)
+		Expect(err).To(MatchError("mirror needs a label or filter"))
+	})
+})
@@ -8,9 +8,23 @@ type OutRequest struct {
 }
 
 type Params struct {
-	ContentPath string `json:"content"`
+	ContentPath  string   `json:"content"`
+	Format       string   `json:"format"`
+	SARIF        SARIF    `json:"sarif"`
+	Flaky        *Flaky   `json:"flaky"`
+	Resolve      *Resolve `json:"resolve"`
+	ManifestPath string   `json:"manifest"`
+	Position     Position `json:"position"`
+	Release      *Release `json:"release"`
+	Todos        *Todos   `json:"todos"`
+	Sync         *Sync    `json:"sync"`
+	Import       *Import  `json:"import"`
+	Mirror       *Mirror  `json:"mirror"`
+	DryRun       bool     `json:"dry_run"`
+	PlanFile     string   `json:"plan_file"`
 }
 
 type OutResponse struct {
-	Version resource.Version `json:"version"`
+	Version  resource.Version        `json:"version"`
+	Metadata []resource.MetadataPair `json:"metadata,omitempty"`
 }
@@ -19,6 +19,7 @@ import (
 	. "github.com/onsi/gomega/gexec"
 
 	"github.com/cjcjameson/tracker-story-resource/out"
+	"github.com/cjcjameson/tracker-story-resource/trackerfake"
 	"github.com/onsi/gomega/ghttp"
 )
 
@@ -85,9 +86,78 @@ var _ = Describe("Out", func() {
 
 	})*/
 
+	Context("when executed against a fake Tracker", func() {
+		var (
+			fake    *trackerfake.Server
+			request out.OutRequest
+		)
+
+		BeforeEach(func() {
+			fake = trackerfake.New("abc", 1234)
+
+			request = out.OutRequest{
+				Source: fake.Source(),
+				Params: out.Params{
+					ContentPath: "junit.xml",
+					Format:      "junit",
+					Flaky:       &out.Flaky{Occurrences: 2},
+				},
+			}
+
+			err := ioutil.WriteFile(filepath.Join(tmpdir, "junit.xml"), []byte(Fixture("junit.xml")), os.ModePerm)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		AfterEach(func() {
+			fake.Close()
+		})
+
+		Context("when Tracker fails partway through a manifest", func() {
+			BeforeEach(func() {
+				request.Params = out.Params{ManifestPath: "manifest.yml"}
+				err := ioutil.WriteFile(filepath.Join(tmpdir, "manifest.yml"), []byte(`
+stories:
+- name: Cover the exhaust port
+- name: Add shielding
+`), os.ModePerm)
+				Expect(err).NotTo(HaveOccurred())
+
+				fake.Inject(trackerfake.Fault{Method: "POST", Path: "/stories", After: 1, Status: 500})
+			})
+
+			It("reports the failure and keeps the stories already created", func() {
+				session := runCommandExpectingStatus(outCmd, request, 1)
+				Expect(session.Err).To(Say("Story created with ID: \\d+ Name: Cover the exhaust port"))
+				Expect(session.Err).To(Say("error creating story: request failed \\(500\\)"))
+
+				stories := fake.Stories()
+				Expect(stories).To(HaveLen(1))
+				Expect(stories[0].Name).To(Equal("Cover the exhaust port"))
+			})
+		})
+
+		It("files failures once and records them recurring", func() {
+			runCommand(outCmd, request)
+
+			stories := fake.Stories()
+			Expect(stories).To(HaveLen(2))
+
+			runCommand(exec.Command(outPath, tmpdir), request)
+
+			Expect(fake.Stories()).To(HaveLen(2))
+			for _, story := range fake.Stories() {
+				Expect(fake.Comments(story.ID)).To(HaveLen(1))
+				Expect(resource.HasLabel(story, "flaky")).To(BeTrue())
+
+				marker, found := out.ParseMarker(story.Description)
+				Expect(found).To(BeTrue())
+				Expect(marker.Count).To(Equal(2))
+			}
+		})
+	})
+
 	Context("when executed against a mock URL", func() {
 		var request out.OutRequest
-		var response out.OutResponse
 
 		var server *ghttp.Server
 
@@ -107,21 +177,20 @@ var _ = Describe("Out", func() {
 				},
 				Params: out.Params{},
 			}
-			response = out.OutResponse{}
 		})
 
 		AfterEach(func() {
 			server.Close()
 		})
 
-		FContext("without a content file specified", func() {
+		Context("without a content file specified", func() {
 			It("raises error", func() {
 				session := runCommandExpectingStatus(outCmd, request, 1)
 				Expect(session.Err).To(Say("no content file specified"))
 			})
 		})
 
-		FContext("when a content file is specified that doesn't exist", func() {
+		Context("when a content file is specified that doesn't exist", func() {
 			It("raises error", func() {
 				contentPath := "blah"
 				request.Params.ContentPath = contentPath
@@ -130,7 +199,7 @@ var _ = Describe("Out", func() {
 			})
 		})
 
-		FContext("when a content file is specified with one story", func() {
+		Context("when a content file is specified with one story", func() {
 			BeforeEach(func() {
 				contentPath := "tracker-resource-content"
 				request.Params.ContentPath = contentPath
@@ -148,6 +217,661 @@ var _ = Describe("Out", func() {
 				os.Remove(request.Params.ContentPath)
 			})
 		})
+
+		Context("when a manifest is specified", func() {
+			BeforeEach(func() {
+				request.Params.ManifestPath = "stories.yml"
+				err := ioutil.WriteFile(filepath.Join(tmpdir, "stories.yml"), []byte(`
+stories:
+- name: migrate the database
+  type: feature
+  labels: [db]
+  epic: Migration
+- name: drop the old tables
+  epic: Cleanup
+`), os.ModePerm)
+				Expect(err).NotTo(HaveOccurred())
+
+				server.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("GET", "/services/v5/projects/1234/epics"),
+						ghttp.RespondWith(http.StatusOK, `[{"id": 6, "name": "Migration", "label": {"id": 9, "name": "migration"}}]`),
+					),
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("POST", "/services/v5/projects/1234/epics"),
+						ghttp.VerifyJSON(`{"name": "Cleanup", "label": {"name": "Cleanup"}}`),
+						ghttp.RespondWith(http.StatusOK, `{"id": 7, "name": "Cleanup", "label": {"id": 10, "name": "cleanup"}}`),
+					),
+					ghttp.CombineHandlers(
+						createStoryHandler(trackerToken, projectId),
+						ghttp.VerifyJSON(`{
+							"name": "migrate the database",
+							"story_type": "feature",
+							"current_state": "unscheduled",
+							"labels": [{"name": "db"}, {"name": "migration"}]
+						}`),
+					),
+					ghttp.CombineHandlers(
+						createStoryHandler(trackerToken, projectId),
+						ghttp.VerifyJSON(`{
+							"name": "drop the old tables",
+							"story_type": "chore",
+							"current_state": "unscheduled",
+							"labels": [{"name": "cleanup"}]
+						}`),
+					),
+				)
+			})
+
+			It("creates every story in the manifest in its epic", func() {
+				session := runCommand(outCmd, request)
+				Expect(server.ReceivedRequests()).To(HaveLen(4))
+				Expect(session.Err).To(Say("Story created with ID: 2300"))
+			})
+		})
+
+		Context("when manifest stories are blocked by other stories", func() {
+			BeforeEach(func() {
+				request.Params.ManifestPath = "stories.yml"
+				err := ioutil.WriteFile(filepath.Join(tmpdir, "stories.yml"), []byte(`
+stories:
+- id: deploy
+  name: deploy it
+  blocked_by: [build, 999]
+- id: build
+  name: build it
+`), os.ModePerm)
+				Expect(err).NotTo(HaveOccurred())
+
+				server.AppendHandlers(
+					ghttp.CombineHandlers(
+						createStoryHandler(trackerToken, projectId),
+						ghttp.VerifyJSON(`{"name": "build it", "story_type": "chore", "current_state": "unscheduled"}`),
+					),
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("POST", "/services/v5/projects/1234/stories"),
+						ghttp.VerifyJSON(`{"name": "deploy it", "story_type": "chore", "current_state": "unscheduled"}`),
+						ghttp.RespondWith(http.StatusOK, `{"id": 2301, "name": "deploy it"}`),
+					),
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("POST", "/services/v5/projects/1234/stories/2301/blockers"),
+						ghttp.VerifyJSON(`{"description": "#2300", "resolved": false}`),
+						ghttp.RespondWith(http.StatusOK, `{"id": 1}`),
+					),
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("POST", "/services/v5/projects/1234/stories/2301/blockers"),
+						ghttp.VerifyJSON(`{"description": "#999", "resolved": false}`),
+						ghttp.RespondWith(http.StatusOK, `{"id": 2}`),
+					),
+				)
+			})
+
+			It("creates the blockers once every story exists", func() {
+				session := runCommand(outCmd, request)
+				Expect(server.ReceivedRequests()).To(HaveLen(4))
+				Expect(session.Err).To(Say("Story 2301 blocked by 2300"))
+				Expect(session.Err).To(Say("Story 2301 blocked by 999"))
+			})
+		})
+
+		Context("when manifest stories block each other", func() {
+			BeforeEach(func() {
+				request.Params.ManifestPath = "stories.yml"
+				err := ioutil.WriteFile(filepath.Join(tmpdir, "stories.yml"), []byte(`
+stories:
+- id: chicken
+  blocked_by: [egg]
+- id: egg
+  blocked_by: [chicken]
+`), os.ModePerm)
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			It("raises error without creating anything", func() {
+				session := runCommandExpectingStatus(outCmd, request, 1)
+				Expect(session.Err).To(Say("blocker cycle: chicken -> egg -> chicken"))
+				Expect(server.ReceivedRequests()).To(BeEmpty())
+			})
+		})
+
+		Context("when a manifest story has an unknown type", func() {
+			BeforeEach(func() {
+				request.Params.ManifestPath = "stories.yml"
+				err := ioutil.WriteFile(filepath.Join(tmpdir, "stories.yml"), []byte(`
+stories:
+- name: migrate the database
+- name: drop the old tables
+  type: task
+`), os.ModePerm)
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			It("raises error without creating anything", func() {
+				session := runCommandExpectingStatus(outCmd, request, 1)
+				Expect(session.Err).To(Say(`unknown story type "task" for story "drop the old tables"`))
+				Expect(server.ReceivedRequests()).To(BeEmpty())
+			})
+		})
+
+		Context("when a manifest is placed at the top of the backlog", func() {
+			BeforeEach(func() {
+				request.Params.ManifestPath = "stories.yml"
+				request.Params.Position = out.Position{TopOfBacklog: true}
+				err := ioutil.WriteFile(filepath.Join(tmpdir, "stories.yml"), []byte(`
+stories:
+- name: build it
+- name: deploy it
+`), os.ModePerm)
+				Expect(err).NotTo(HaveOccurred())
+
+				server.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("GET", "/services/v5/projects/1234/stories", "with_state=unstarted&limit=1"),
+						ghttp.RespondWith(http.StatusOK, Fixture("stories.json")),
+					),
+					ghttp.CombineHandlers(
+						createStoryHandler(trackerToken, projectId),
+						ghttp.VerifyJSON(`{"name": "build it", "story_type": "chore", "current_state": "unstarted", "before_id": 565}`),
+					),
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("POST", "/services/v5/projects/1234/stories"),
+						ghttp.VerifyJSON(`{"name": "deploy it", "story_type": "chore", "after_id": 2300}`),
+						ghttp.RespondWith(http.StatusOK, `{"id": 2301, "name": "deploy it"}`),
+					),
+				)
+			})
+
+			It("keeps the stories in the manifest's order", func() {
+				session := runCommand(outCmd, request)
+				Expect(server.ReceivedRequests()).To(HaveLen(3))
+				Expect(session.Err).To(Say("Story created with ID: 2300 Name: fake-story-name"))
+				Expect(session.Err).To(Say("Story created with ID: 2301 Name: deploy it"))
+			})
+		})
+
+		Context("when a release is specified", func() {
+			labelHandler := func(storyID int) http.HandlerFunc {
+				return ghttp.CombineHandlers(
+					ghttp.VerifyRequest("POST", fmt.Sprintf("/services/v5/projects/1234/stories/%d/labels", storyID)),
+					ghttp.VerifyJSON(`{"name": "v1.1.0"}`),
+					ghttp.RespondWith(http.StatusOK, `{"id": 3, "name": "v1.1.0"}`),
+				)
+			}
+
+			BeforeEach(func() {
+				request.Params.Release = &out.Release{
+					Repo:     "git",
+					Deadline: "2026-11-01",
+				}
+
+				server.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("GET", "/services/v5/projects/1234/stories", "with_state=delivered&limit=1"),
+						ghttp.RespondWith(http.StatusOK, `[{"id": 565}]`, http.Header{
+							"X-Tracker-Pagination-Total": []string{"1"},
+						}),
+					),
+					ghttp.CombineHandlers(
+						createStoryHandler(trackerToken, projectId),
+						ghttp.VerifyJSON(`{
+							"name": "v1.1.0",
+							"story_type": "release",
+							"deadline": "2026-11-01T00:00:00Z",
+							"after_id": 565
+						}`),
+					),
+					labelHandler(723456),
+					labelHandler(623456),
+					labelHandler(523456),
+					labelHandler(423456),
+					labelHandler(789456),
+				)
+			})
+
+			It("creates a release marker after the last delivered story", func() {
+				session := runCommand(outCmd, request)
+				Expect(session.Err).To(Say("Release created with ID: 2300"))
+			})
+
+			It("labels the stories committed since the previous tag", func() {
+				session := runCommand(outCmd, request)
+				Expect(server.ReceivedRequests()).To(HaveLen(7))
+				Expect(session.Err).To(Say("Story 723456 labeled v1.1.0"))
+				Expect(session.Err).NotTo(Say("323456"))
+			})
+		})
+
+		Context("when a JUnit report is specified", func() {
+			BeforeEach(func() {
+				request.Params.ContentPath = "junit.xml"
+				request.Params.Format = "junit"
+				err := ioutil.WriteFile(filepath.Join(tmpdir, "junit.xml"), []byte(Fixture("junit.xml")), os.ModePerm)
+				Expect(err).NotTo(HaveOccurred())
+
+				server.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("GET", "/services/v5/projects/1234/stories", "filter=-state:accepted&limit=100"),
+						ghttp.RespondWith(http.StatusOK, `[
+							{"id": 77, "name": "deathstar.TractorBeamTest/testPower", "story_type": "bug", "created_at": "2026-10-18T12:00:00Z",
+							 "description": "old\n\n<!-- tracker-story-resource {\"fingerprint\":\"junit:deathstar.TractorBeamTest/testPower\"} -->"}
+						]`),
+					),
+					ghttp.CombineHandlers(
+						createStoryHandler(trackerToken, projectId),
+						verifyStory(func(story tracker.Story) {
+							Expect(story.Name).To(Equal("deathstar.TractorBeamTest/testReleasesShips"))
+							Expect(story.Type).To(BeEquivalentTo(tracker.StoryTypeBug))
+							Expect(story.Description).To(HavePrefix("expected ship to be released\n\n```\nAssertionError: expected ship to be released\n\tat deathstar.TractorBeamTest.testReleasesShips(TractorBeamTest.java:42)\n```\n\n"))
+
+							marker, found := out.ParseMarker(story.Description)
+							Expect(found).To(BeTrue())
+							Expect(marker.Fingerprint).To(Equal("junit:deathstar.TractorBeamTest/testReleasesShips"))
+							Expect(marker.Occurrences).To(HaveLen(1))
+						}),
+					),
+				)
+			})
+
+			Context("when a failure already has a story", func() {
+				BeforeEach(func() {
+					request.Params.Flaky = &out.Flaky{Occurrences: 2}
+
+					server.AppendHandlers(
+						ghttp.CombineHandlers(
+							ghttp.VerifyRequest("PUT", "/services/v5/projects/1234/stories/77"),
+							verifyStory(func(story tracker.Story) {
+								Expect(story.Description).To(HavePrefix("old\n\n"))
+
+								marker, found := out.ParseMarker(story.Description)
+								Expect(found).To(BeTrue())
+								Expect(marker.Fingerprint).To(Equal("junit:deathstar.TractorBeamTest/testPower"))
+								Expect(marker.Count).To(Equal(2))
+								Expect(marker.Occurrences).To(HaveLen(1))
+							}),
+							ghttp.RespondWith(http.StatusOK, `{"id": 77}`),
+						),
+						ghttp.CombineHandlers(
+							ghttp.VerifyRequest("POST", "/services/v5/projects/1234/stories/77/comments"),
+							ghttp.VerifyJSON(`{"text": "Failed again in http://ci.example.com/teams/main/pipelines/nightly/jobs/integration/builds/42 (occurrence 2)."}`),
+							ghttp.RespondWith(http.StatusOK, `{"id": 1}`),
+						),
+						ghttp.CombineHandlers(
+							ghttp.VerifyRequest("POST", "/services/v5/projects/1234/stories/77/labels"),
+							ghttp.VerifyJSON(`{"name": "flaky"}`),
+							ghttp.RespondWith(http.StatusOK, `{"id": 3, "name": "flaky"}`),
+						),
+					)
+
+					outCmd.Env = append(os.Environ(),
+						"ATC_EXTERNAL_URL=http://ci.example.com",
+						"BUILD_PIPELINE_NAME=nightly",
+						"BUILD_JOB_NAME=integration",
+						"BUILD_NAME=42",
+					)
+				})
+
+				It("files new failures and comments on recurring ones", func() {
+					session := runCommand(outCmd, request)
+					Expect(server.ReceivedRequests()).To(HaveLen(5))
+					Expect(session.Err).To(Say("Story 77 failed again \\(occurrence 2\\)"))
+					Expect(session.Err).To(Say("Story 77 labeled flaky"))
+				})
+			})
+		})
+
+		Context("when a passing report is specified with resolve", func() {
+			BeforeEach(func() {
+				request.Params.ContentPath = "junit.xml"
+				request.Params.Format = "junit"
+				request.Params.Resolve = &out.Resolve{Finish: true, DeleteUnscheduled: true}
+				err := ioutil.WriteFile(filepath.Join(tmpdir, "junit.xml"), []byte(`
+<testsuite name="suite">
+  <testcase name="started"/>
+  <testcase name="iceboxed"/>
+  <testcase name="already-passed"/>
+</testsuite>`), os.ModePerm)
+				Expect(err).NotTo(HaveOccurred())
+
+				server.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("GET", "/services/v5/projects/1234/stories", "filter=-state:accepted&limit=100"),
+						ghttp.RespondWith(http.StatusOK, `[
+							{"id": 80, "current_state": "started", "description": "<!-- tracker-story-resource {\"fingerprint\":\"junit:suite/started\"} -->"},
+							{"id": 81, "current_state": "unscheduled", "description": "<!-- tracker-story-resource {\"fingerprint\":\"junit:suite/iceboxed\"} -->"},
+							{"id": 82, "current_state": "started", "description": "<!-- tracker-story-resource {\"fingerprint\":\"junit:suite/already-passed\",\"passed_at\":\"2026-10-18T12:00:00Z\"} -->"},
+							{"id": 83, "current_state": "started", "description": "<!-- tracker-story-resource {\"fingerprint\":\"junit:suite/not-run\"} -->"}
+						]`),
+					),
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("PUT", "/services/v5/projects/1234/stories/80"),
+						verifyStory(func(story tracker.Story) {
+							Expect(story.State).To(BeEquivalentTo(tracker.StoryStateFinished))
+
+							marker, found := out.ParseMarker(story.Description)
+							Expect(found).To(BeTrue())
+							Expect(marker.PassedAt).NotTo(BeNil())
+						}),
+						ghttp.RespondWith(http.StatusOK, `{"id": 80}`),
+					),
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("POST", "/services/v5/projects/1234/stories/80/comments"),
+						ghttp.VerifyJSON(`{"text": "Passed again."}`),
+						ghttp.RespondWith(http.StatusOK, `{"id": 1}`),
+					),
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("DELETE", "/services/v5/projects/1234/stories/81"),
+						ghttp.RespondWith(http.StatusNoContent, ""),
+					),
+				)
+			})
+
+			It("resolves the stories of checks that pass again", func() {
+				session := runCommand(outCmd, request)
+				Expect(server.ReceivedRequests()).To(HaveLen(4))
+				Expect(session.Err).To(Say("Story 80 passed and was finished"))
+				Expect(session.Err).To(Say("Story 81 passed and was deleted"))
+			})
+		})
+
+		Context("when a vulnerability report is specified", func() {
+			BeforeEach(func() {
+				request.Params.ContentPath = "trivy.json"
+				request.Params.Format = "trivy"
+				err := ioutil.WriteFile(filepath.Join(tmpdir, "trivy.json"), []byte(Fixture("trivy.json")), os.ModePerm)
+				Expect(err).NotTo(HaveOccurred())
+
+				server.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("GET", "/services/v5/projects/1234/stories", "filter=-state:accepted&limit=100"),
+						ghttp.RespondWith(http.StatusOK, `[
+							{"id": 90, "current_state": "started", "description": "<!-- tracker-story-resource {\"fingerprint\":\"vuln:CVE-2024-0727:libcrypto3\"} -->"}
+						]`),
+					),
+					ghttp.CombineHandlers(
+						createStoryHandler(trackerToken, projectId),
+						verifyStory(func(story tracker.Story) {
+							Expect(story.Name).To(Equal("CVE-2024-0727 in libssl3"))
+							Expect(story.Labels).To(Equal([]tracker.Label{{Name: "vulnerability"}, {Name: "severity:medium"}}))
+						}),
+					),
+				)
+			})
+
+			It("files new vulnerabilities and leaves known ones alone", func() {
+				session := runCommand(outCmd, request)
+				Expect(server.ReceivedRequests()).To(HaveLen(2))
+				Expect(session.Err).To(Say("Story already filed for vuln:CVE-2024-0727:libcrypto3 with ID: 90"))
+			})
+		})
+
+		Context("when an Alertmanager webhook is specified", func() {
+			BeforeEach(func() {
+				request.Params.ContentPath = "alerts.json"
+				request.Params.Format = "alertmanager"
+				err := ioutil.WriteFile(filepath.Join(tmpdir, "alerts.json"), []byte(Fixture("alertmanager.json")), os.ModePerm)
+				Expect(err).NotTo(HaveOccurred())
+
+				server.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("GET", "/services/v5/projects/1234/stories", "filter=-state:accepted&limit=100"),
+						ghttp.RespondWith(http.StatusOK, `[
+							{"id": 95, "current_state": "started", "description": "<!-- tracker-story-resource {\"fingerprint\":\"alert:9c8d7e\"} -->"},
+							{"id": 96, "current_state": "started", "description": "<!-- tracker-story-resource {\"fingerprint\":\"alert:3f2a1b\"} -->"}
+						]`),
+					),
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("GET", "/services/v5/projects/1234/stories", "with_state=unstarted&limit=1"),
+						ghttp.RespondWith(http.StatusOK, `[{"id": 555}]`),
+					),
+					ghttp.CombineHandlers(
+						createStoryHandler(trackerToken, projectId),
+						verifyStory(func(story tracker.Story) {
+							Expect(story.Name).To(Equal("ExhaustPortOpen"))
+							Expect(story.Type).To(BeEquivalentTo(tracker.StoryTypeBug))
+							Expect(story.State).To(BeEquivalentTo(tracker.StoryStateUnstarted))
+							Expect(story.BeforeID).To(Equal(555))
+						}),
+					),
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("PUT", "/services/v5/projects/1234/stories/95"),
+						verifyStory(func(story tracker.Story) {
+							marker, found := out.ParseMarker(story.Description)
+							Expect(found).To(BeTrue())
+							Expect(*marker.PassedAt).To(Equal(time.Date(2026, 10, 19, 9, 0, 0, 0, time.UTC)))
+						}),
+						ghttp.RespondWith(http.StatusOK, `{"id": 95}`),
+					),
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("POST", "/services/v5/projects/1234/stories/95/comments"),
+						ghttp.VerifyJSON(`{"text": "Resolved at 2026-10-19T09:00:00Z."}`),
+						ghttp.RespondWith(http.StatusOK, `{"id": 1}`),
+					),
+				)
+			})
+
+			It("files firing alerts at the top of the backlog and comments on resolved ones", func() {
+				session := runCommand(outCmd, request)
+				Expect(server.ReceivedRequests()).To(HaveLen(5))
+				Expect(session.Err).To(Say("Story already filed for alert:3f2a1b with ID: 96"))
+				Expect(session.Err).To(Say("Story created with ID: 2300"))
+				Expect(session.Err).To(Say("Story 95 resolved"))
+			})
+		})
+
+		Context("when todos are specified", func() {
+			BeforeEach(func() {
+				request.Params.Todos = &out.Todos{Repo: "todos"}
+
+				server.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("GET", "/services/v5/projects/1234/stories", "filter=-state:accepted&limit=100"),
+						ghttp.RespondWith(http.StatusOK, `[
+							{"id": 100, "current_state": "unstarted", "description": "<!-- tracker-story-resource {\"fingerprint\":\"todo:todos:reactor.go:FIXME shielding drops under load\"} -->"},
+							{"id": 101, "current_state": "unstarted", "description": "<!-- tracker-story-resource {\"fingerprint\":\"todo:todos:reactor.go:TODO(galen): recalibrate\"} -->"},
+							{"id": 102, "current_state": "unstarted", "description": "<!-- tracker-story-resource {\"fingerprint\":\"todo:other:main.go:FIXME\"} -->"},
+							{"id": 103, "current_state": "unstarted", "description": "<!-- tracker-story-resource {\"fingerprint\":\"todo:vendor/todos:reactor.go:TODO(galen): recalibrate\"} -->"}
+						]`),
+					),
+					ghttp.CombineHandlers(
+						createStoryHandler(trackerToken, projectId),
+						verifyStory(func(story tracker.Story) {
+							Expect(story.Name).To(Equal("TODO(galen): cover the exhaust port"))
+							Expect(story.Type).To(BeEquivalentTo(tracker.StoryTypeChore))
+							Expect(story.Labels).To(Equal([]tracker.Label{{Name: "todo"}}))
+							Expect(story.Description).To(HavePrefix("`reactor.go:3` by Concourse Tracker Resource\n\nhttps://github.com/deathstar/reactor/blob/"))
+							Expect(story.Description).To(ContainSubstring("/reactor.go#L3\n"))
+
+							marker, found := out.ParseMarker(story.Description)
+							Expect(found).To(BeTrue())
+							Expect(marker.Fingerprint).To(Equal("todo:todos:reactor.go:TODO(galen): cover the exhaust port"))
+						}),
+					),
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("PUT", "/services/v5/projects/1234/stories/100"),
+						verifyStory(func(story tracker.Story) {
+							Expect(story.Description).To(HavePrefix("`reactor.go:5`"))
+						}),
+						ghttp.RespondWith(http.StatusOK, `{"id": 100}`),
+					),
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("PUT", "/services/v5/projects/1234/stories/101"),
+						ghttp.VerifyJSON(`{"id": 101, "current_state": "accepted"}`),
+						ghttp.RespondWith(http.StatusOK, `{"id": 101}`),
+					),
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("POST", "/services/v5/projects/1234/stories/101/comments"),
+						ghttp.VerifyJSON(`{"text": "The marker was removed from the code."}`),
+						ghttp.RespondWith(http.StatusOK, `{"id": 1}`),
+					),
+				)
+			})
+
+			It("files chores for new markers and closes the ones that are gone from this repo", func() {
+				session := runCommand(outCmd, request)
+				Expect(server.ReceivedRequests()).To(HaveLen(5))
+				Expect(session.Err).To(Say("Story created with ID: 2300"))
+				Expect(session.Err).To(Say("Story 100 moved"))
+				Expect(session.Err).To(Say("Story 101 closed"))
+			})
+		})
+
+		Context("when a position is specified", func() {
+			BeforeEach(func() {
+				contentPath := "tracker-resource-content"
+				request.Params.ContentPath = contentPath
+				err := ioutil.WriteFile(filepath.Join(tmpdir, contentPath), []byte("fake-story-name"), os.ModePerm)
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			Context("at the top of the backlog", func() {
+				BeforeEach(func() {
+					request.Params.Position = out.Position{TopOfBacklog: true}
+
+					server.AppendHandlers(
+						ghttp.CombineHandlers(
+							ghttp.VerifyRequest("GET", "/services/v5/projects/1234/stories", "with_state=unstarted&limit=1"),
+							ghttp.RespondWith(http.StatusOK, Fixture("stories.json")),
+						),
+						ghttp.CombineHandlers(
+							createStoryHandler(trackerToken, projectId),
+							ghttp.VerifyJSON(`{
+								"name": "fake-story-name",
+								"story_type": "chore",
+								"current_state": "unstarted",
+								"before_id": 565
+							}`),
+						),
+					)
+				})
+
+				It("creates the story before the first story in the backlog", func() {
+					session := runCommand(outCmd, request)
+					Expect(session.Err).To(Say("Story created with ID: 2300"))
+				})
+			})
+
+			Context("at the bottom of the backlog", func() {
+				BeforeEach(func() {
+					request.Params.Position = out.Position{BottomOfBacklog: true}
+
+					server.AppendHandlers(
+						ghttp.CombineHandlers(
+							ghttp.VerifyRequest("GET", "/services/v5/projects/1234/stories", "with_state=unstarted&limit=1"),
+							ghttp.RespondWith(http.StatusOK, `[{"id": 565}]`, http.Header{
+								"X-Tracker-Pagination-Total": []string{"3"},
+							}),
+						),
+						ghttp.CombineHandlers(
+							ghttp.VerifyRequest("GET", "/services/v5/projects/1234/stories", "with_state=unstarted&limit=1&offset=2"),
+							ghttp.RespondWith(http.StatusOK, `[{"id": 789}]`),
+						),
+						ghttp.CombineHandlers(
+							createStoryHandler(trackerToken, projectId),
+							ghttp.VerifyJSON(`{
+								"name": "fake-story-name",
+								"story_type": "chore",
+								"current_state": "unstarted",
+								"after_id": 789
+							}`),
+						),
+					)
+				})
+
+				It("creates the story after the last story in the backlog", func() {
+					session := runCommand(outCmd, request)
+					Expect(session.Err).To(Say("Story created with ID: 2300"))
+				})
+			})
+
+			Context("before another story", func() {
+				BeforeEach(func() {
+					request.Params.Position = out.Position{BeforeID: 42}
+
+					server.AppendHandlers(
+						ghttp.CombineHandlers(
+							createStoryHandler(trackerToken, projectId),
+							ghttp.VerifyJSON(`{
+								"name": "fake-story-name",
+								"story_type": "chore",
+								"before_id": 42
+							}`),
+						),
+					)
+				})
+
+				It("lets Tracker place it next to that story", func() {
+					session := runCommand(outCmd, request)
+					Expect(session.Err).To(Say("Story created with ID: 2300"))
+				})
+			})
+
+			Context("in the current iteration", func() {
+				BeforeEach(func() {
+					request.Params.Position = out.Position{IntoCurrentIteration: true}
+				})
+
+				Context("of a project that plans its iterations by hand", func() {
+					BeforeEach(func() {
+						server.AppendHandlers(
+							ghttp.CombineHandlers(
+								ghttp.VerifyRequest("GET", "/services/v5/projects/1234"),
+								ghttp.RespondWith(http.StatusOK, `{"id": 1234, "automatic_planning": false}`),
+							),
+							ghttp.CombineHandlers(
+								createStoryHandler(trackerToken, projectId),
+								ghttp.VerifyJSON(`{
+									"name": "fake-story-name",
+									"story_type": "chore",
+									"current_state": "planned"
+								}`),
+							),
+						)
+					})
+
+					It("creates a planned story", func() {
+						session := runCommand(outCmd, request)
+						Expect(session.Err).To(Say("Story created with ID: 2300"))
+					})
+				})
+
+				Context("of a project that plans its iterations automatically", func() {
+					BeforeEach(func() {
+						server.AppendHandlers(
+							ghttp.CombineHandlers(
+								ghttp.VerifyRequest("GET", "/services/v5/projects/1234"),
+								ghttp.RespondWith(http.StatusOK, `{"id": 1234, "automatic_planning": true}`),
+							),
+							ghttp.CombineHandlers(
+								ghttp.VerifyRequest("GET", "/services/v5/projects/1234/stories", "with_state=unstarted&limit=1"),
+								ghttp.RespondWith(http.StatusOK, Fixture("stories.json")),
+							),
+							ghttp.CombineHandlers(
+								createStoryHandler(trackerToken, projectId),
+								ghttp.VerifyJSON(`{
+									"name": "fake-story-name",
+									"story_type": "chore",
+									"current_state": "unstarted",
+									"before_id": 565
+								}`),
+							),
+						)
+					})
+
+					It("creates the story ahead of the current iteration's first unstarted story", func() {
+						session := runCommand(outCmd, request)
+						Expect(session.Err).To(Say("Story created with ID: 2300"))
+					})
+				})
+			})
+
+			Context("with more than one position", func() {
+				It("raises error", func() {
+					request.Params.Position = out.Position{TopOfBacklog: true, AfterID: 42}
+					session := runCommandExpectingStatus(outCmd, request, 1)
+					Expect(session.Err).To(Say("only one position may be given"))
+				})
+			})
+		})
 	})
 })
 
@@ -179,6 +903,16 @@ func createStoryHandler(token string, projectId string) http.HandlerFunc {
 	)
 }
 
+func verifyStory(verify func(tracker.Story)) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		var story tracker.Story
+		err := json.NewDecoder(req.Body).Decode(&story)
+		Expect(err).NotTo(HaveOccurred())
+
+		verify(story)
+	}
+}
+
 func listStoriesHandler(trackerToken string) http.HandlerFunc {
 	return ghttp.CombineHandlers(
 		ghttp.VerifyRequest("GET", "/services/v5/projects/1234/stories"),
@@ -0,0 +1,387 @@
+package out
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/XenoPhex/go-tracker"
+	"github.com/cjcjameson/tracker-story-resource"
+)
+
+// Writer is resource.Writer, which a Plan implements.
+type Writer = resource.Writer
+
+type Action string
+
+const (
+	ActionCreate     Action = "create"
+	ActionUpdate     Action = "update"
+	ActionDelete     Action = "delete"
+	ActionLabel      Action = "label"
+	ActionComment    Action = "comment"
+	ActionBlock      Action = "block"
+	ActionAddTask    Action = "add_task"
+	ActionRemoveTask Action = "remove_task"
+	ActionCreateEpic Action = "create_epic"
+)
+
+// Change is one write a put would make. Stories the plan would create have
+// negative IDs, so that later changes can refer to them. ProjectID is only
+// set for changes to a project other than the put's.
+type Change struct {
+	Action    Action `json:"action"`
+	ProjectID int    `json:"project_id,omitempty"`
+	StoryID   int    `json:"story_id,omitempty"`
+
+	// Story is the story created, or the fields an update sends.
+	Story *tracker.Story `json:"story,omitempty"`
+
+	// Before is the story an update or delete changes, as it is now.
+	Before *tracker.Story `json:"before,omitempty"`
+
+	Label   string `json:"label,omitempty"`
+	Comment string `json:"comment,omitempty"`
+	Blocker string `json:"blocker,omitempty"`
+	Task    string `json:"task,omitempty"`
+	Epic    string `json:"epic,omitempty"`
+}
+
+// Plan records the changes a put would make instead of making them. It reads
+// the stories it changes from the project, to show what they change.
+type Plan struct {
+	Changes []Change `json:"changes"`
+
+	client  resource.Reader
+	project int
+	root    *Plan
+	created map[int]tracker.Story
+}
+
+func NewPlan(client resource.Reader) *Plan {
+	plan := &Plan{
+		Changes: []Change{},
+		client:  client,
+		created: map[int]tracker.Story{},
+	}
+	plan.root = plan
+
+	return plan
+}
+
+// InProject records the changes the put would make to another project in the
+// same plan.
+func (p *Plan) InProject(client resource.Reader, projectID int) *Plan {
+	return &Plan{
+		client:  client,
+		project: projectID,
+		root:    p.root,
+		created: p.root.created,
+	}
+}
+
+func (p *Plan) add(change Change) {
+	change.ProjectID = p.project
+	p.root.Changes = append(p.root.Changes, change)
+}
+
+func (p *Plan) CreateStory(story tracker.Story) (tracker.Story, error) {
+	story.ID = -(len(p.created) + 1)
+	p.created[story.ID] = story
+
+	p.add(Change{Action: ActionCreate, StoryID: story.ID, Story: &story})
+	return story, nil
+}
+
+func (p *Plan) UpdateStory(update tracker.Story) (tracker.Story, error) {
+	current, err := p.story(update.ID)
+	if err != nil {
+		return tracker.Story{}, err
+	}
+
+	p.add(Change{Action: ActionUpdate, StoryID: update.ID, Story: &update, Before: &current})
+	return updated(current, update), nil
+}
+
+func (p *Plan) DeleteStory(storyID int) error {
+	current, err := p.story(storyID)
+	if err != nil {
+		return err
+	}
+
+	p.add(Change{Action: ActionDelete, StoryID: storyID, Before: &current})
+	return nil
+}
+
+func (p *Plan) AddStoryLabel(storyID int, label tracker.Label) (tracker.Label, error) {
+	p.add(Change{Action: ActionLabel, StoryID: storyID, Label: label.Name})
+	return label, nil
+}
+
+func (p *Plan) CreateComment(storyID int, comment tracker.Comment) (tracker.Comment, error) {
+	p.add(Change{Action: ActionComment, StoryID: storyID, Comment: comment.Text})
+	return comment, nil
+}
+
+func (p *Plan) CreateBlocker(storyID int, blocker tracker.Blocker) (tracker.Blocker, error) {
+	p.add(Change{Action: ActionBlock, StoryID: storyID, Blocker: blocker.Description})
+	return blocker, nil
+}
+
+func (p *Plan) CreateTask(storyID int, task tracker.Task) (tracker.Task, error) {
+	p.add(Change{Action: ActionAddTask, StoryID: storyID, Task: task.Description})
+	return task, nil
+}
+
+func (p *Plan) DeleteTask(storyID int, taskID int) error {
+	tasks, err := p.client.StoryTasks(storyID)
+	if err != nil {
+		return fmt.Errorf("fetching tasks of story %d: %s", storyID, err)
+	}
+
+	change := Change{Action: ActionRemoveTask, StoryID: storyID, Task: fmt.Sprintf("#%d", taskID)}
+	for _, task := range tasks {
+		if task.ID == taskID {
+			change.Task = task.Description
+		}
+	}
+
+	p.add(change)
+	return nil
+}
+
+func (p *Plan) CreateEpic(epic tracker.Epic) (tracker.Epic, error) {
+	p.add(Change{Action: ActionCreateEpic, Epic: epic.Name})
+	return epic, nil
+}
+
+func (p *Plan) story(storyID int) (tracker.Story, error) {
+	if story, found := p.created[storyID]; found {
+		return story, nil
+	}
+
+	story, err := p.client.Story(storyID)
+	if err != nil {
+		return tracker.Story{}, fmt.Errorf("fetching story %d: %s", storyID, err)
+	}
+
+	return story, nil
+}
+
+// updated is the story as Tracker would return it after the update.
+func updated(story tracker.Story, update tracker.Story) tracker.Story {
+	if update.Name != "" {
+		story.Name = update.Name
+	}
+
+	if update.Description != "" {
+		story.Description = update.Description
+	}
+
+	if update.Type != "" {
+		story.Type = update.Type
+	}
+
+	if update.State != "" {
+		story.State = update.State
+	}
+
+	if update.Labels != nil {
+		story.Labels = update.Labels
+	}
+
+	if update.Estimate != nil {
+		story.Estimate = update.Estimate
+	}
+
+	return story
+}
+
+// String shows the plan as a diff: + for what would be added, - for what
+// would be removed and ~ for stories that would change.
+func (p *Plan) String() string {
+	if len(p.Changes) == 0 {
+		return "No changes.\n"
+	}
+
+	var buffer bytes.Buffer
+	for _, change := range p.Changes {
+		switch change.Action {
+		case ActionCreate:
+			fmt.Fprintf(&buffer, "+ create %s %q%s\n", change.Story.Type, change.Story.Name, placement(*change.Story))
+			writeLines(&buffer, "    + ", change.Story.Description)
+		case ActionUpdate:
+			fmt.Fprintf(&buffer, "~ update %s %q\n", changeRef(change), change.Before.Name)
+			writeUpdate(&buffer, *change.Before, *change.Story)
+		case ActionDelete:
+			fmt.Fprintf(&buffer, "- delete %s %q\n", changeRef(change), change.Before.Name)
+		case ActionLabel:
+			fmt.Fprintf(&buffer, "~ label %s %s\n", changeRef(change), change.Label)
+		case ActionComment:
+			fmt.Fprintf(&buffer, "~ comment on %s\n", changeRef(change))
+			writeLines(&buffer, "    + ", change.Comment)
+		case ActionBlock:
+			blocker := change.Blocker
+			if id, err := strconv.Atoi(strings.TrimPrefix(blocker, "#")); err == nil {
+				blocker = storyRef(id)
+			}
+
+			fmt.Fprintf(&buffer, "~ block %s by %s\n", changeRef(change), blocker)
+		case ActionAddTask:
+			fmt.Fprintf(&buffer, "~ add task to %s\n", changeRef(change))
+			writeLines(&buffer, "    + ", change.Task)
+		case ActionRemoveTask:
+			fmt.Fprintf(&buffer, "~ remove task from %s\n", changeRef(change))
+			writeLines(&buffer, "    - ", change.Task)
+		case ActionCreateEpic:
+			fmt.Fprintf(&buffer, "+ create epic %q\n", change.Epic)
+		}
+	}
+
+	fmt.Fprintf(&buffer, "\nPlan: %s.\n", p.Summary())
+
+	return buffer.String()
+}
+
+// Summary counts the changes of each kind, for the put's metadata, where the
+// diff would not fit.
+func (p *Plan) Summary() string {
+	counts := map[Action]int{}
+	for _, change := range p.Changes {
+		counts[change.Action]++
+	}
+
+	return fmt.Sprintf("%d to create, %d to update, %d to delete, %d labels, %d comments, %d blockers, %d epics",
+		counts[ActionCreate], counts[ActionUpdate], counts[ActionDelete],
+		counts[ActionLabel], counts[ActionComment], counts[ActionBlock], counts[ActionCreateEpic])
+}
+
+// changeRef is the story a change is to, naming its project if it is not the
+// put's.
+func changeRef(change Change) string {
+	if change.ProjectID != 0 {
+		return fmt.Sprintf("%s in project %d", storyRef(change.StoryID), change.ProjectID)
+	}
+
+	return storyRef(change.StoryID)
+}
+
+func storyRef(storyID int) string {
+	if storyID < 0 {
+		return fmt.Sprintf("new story %d", -storyID)
+	}
+
+	return fmt.Sprintf("#%d", storyID)
+}
+
+func placement(story tracker.Story) string {
+	var where []string
+	if story.State != "" {
+		where = append(where, string(story.State))
+	}
+
+	if story.BeforeID != 0 {
+		where = append(where, "before "+storyRef(story.BeforeID))
+	}
+
+	if story.AfterID != 0 {
+		where = append(where, "after "+storyRef(story.AfterID))
+	}
+
+	if len(where) == 0 {
+		return ""
+	}
+
+	return " (" + strings.Join(where, ", ") + ")"
+}
+
+func writeUpdate(buffer *bytes.Buffer, before tracker.Story, update tracker.Story) {
+	for _, field := range []struct {
+		name          string
+		before, after string
+	}{
+		{"name", before.Name, update.Name},
+		{"story_type", string(before.Type), string(update.Type)},
+		{"current_state", string(before.State), string(update.State)},
+	} {
+		if field.after != "" && field.after != field.before {
+			fmt.Fprintf(buffer, "    - %s: %s\n    + %s: %s\n", field.name, field.before, field.name, field.after)
+		}
+	}
+
+	if update.Estimate != nil && !sameEstimate(before.Estimate, update.Estimate) {
+		fmt.Fprintf(buffer, "    - estimate: %s\n    + estimate: %s\n", estimate(before.Estimate), estimate(update.Estimate))
+	}
+
+	if update.Labels != nil {
+		for _, line := range diffLines(labelNames(before.Labels), labelNames(update.Labels)) {
+			fmt.Fprintf(buffer, "    %s\n", strings.Replace(line, " ", " label: ", 1))
+		}
+	}
+
+	if update.Description != "" && update.Description != before.Description {
+		for _, line := range diffLines(strings.Split(before.Description, "\n"), strings.Split(update.Description, "\n")) {
+			fmt.Fprintf(buffer, "    %s\n", line)
+		}
+	}
+}
+
+func estimate(points *float64) string {
+	if points == nil {
+		return "none"
+	}
+
+	return strconv.FormatFloat(*points, 'f', -1, 64)
+}
+
+func writeLines(buffer *bytes.Buffer, prefix string, text string) {
+	if text == "" {
+		return
+	}
+
+	for _, line := range strings.Split(text, "\n") {
+		fmt.Fprintf(buffer, "%s%s\n", prefix, line)
+	}
+}
+
+// diffLines lists the lines removed from before and added in after, in order,
+// leaving out the lines they share.
+func diffLines(before, after []string) []string {
+	// common[i][j] is the length of the longest common subsequence of
+	// before[i:] and after[j:]
+	common := make([][]int, len(before)+1)
+	for i := range common {
+		common[i] = make([]int, len(after)+1)
+	}
+
+	for i := len(before) - 1; i >= 0; i-- {
+		for j := len(after) - 1; j >= 0; j-- {
+			if before[i] == after[j] {
+				common[i][j] = common[i+1][j+1] + 1
+			} else if common[i+1][j] >= common[i][j+1] {
+				common[i][j] = common[i+1][j]
+			} else {
+				common[i][j] = common[i][j+1]
+			}
+		}
+	}
+
+	var lines []string
+	i, j := 0, 0
+	for i < len(before) || j < len(after) {
+		switch {
+		case i < len(before) && j < len(after) && before[i] == after[j]:
+			i++
+			j++
+		case j == len(after) || (i < len(before) && common[i+1][j] >= common[i][j+1]):
+			lines = append(lines, "- "+before[i])
+			i++
+		default:
+			lines = append(lines, "+ "+after[j])
+			j++
+		}
+	}
+
+	return lines
+}
@@ -0,0 +1,196 @@
+package out_test
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/XenoPhex/go-tracker"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+
+	"github.com/cjcjameson/tracker-story-resource"
+	"github.com/cjcjameson/tracker-story-resource/out"
+	"github.com/cjcjameson/tracker-story-resource/trackerfake"
+)
+
+var _ = Describe("Plan", func() {
+	var (
+		fake    *trackerfake.Server
+		env     resource.Env
+		log     *gbytes.Buffer
+		sources string
+	)
+
+	BeforeEach(func() {
+		fake = trackerfake.New("abc", 1234)
+
+		client, err := resource.NewProjectClient(fake.Source())
+		Expect(err).NotTo(HaveOccurred())
+
+		log = gbytes.NewBuffer()
+		now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+		env = resource.Env{
+			Client: client,
+			Log:    log,
+			Now:    func() time.Time { return now },
+		}
+
+		sources, err = ioutil.TempDir("", "out-plan")
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		fake.Close()
+		os.RemoveAll(sources)
+	})
+
+	mutations := func() []string {
+		var mutating []string
+		for _, request := range fake.Requests() {
+			if !strings.HasPrefix(request, "GET ") {
+				mutating = append(mutating, request)
+			}
+		}
+		return mutating
+	}
+
+	Context("with a manifest", func() {
+		BeforeEach(func() {
+			fake.AddStory(tracker.Story{Name: "Guard the generator", State: tracker.StoryStateUnstarted})
+
+			err := ioutil.WriteFile(filepath.Join(sources, "stories.yml"), []byte(`
+stories:
+- id: deploy
+  name: deploy it
+  epic: Shield
+  blocked_by: [build]
+- id: build
+  name: build it
+  type: bug
+`), os.ModePerm)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("shows the stories, epics and blockers it would create without creating them", func() {
+			response, err := out.Run(context.Background(), out.OutRequest{
+				Params: out.Params{
+					ManifestPath: "stories.yml",
+					Position:     out.Position{TopOfBacklog: true},
+					DryRun:       true,
+					PlanFile:     "plan.json",
+				},
+			}, sources, env)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(response.Metadata).To(ContainElement(resource.MetadataPair{
+				Name:  "plan",
+				Value: "2 to create, 0 to update, 0 to delete, 0 labels, 0 comments, 1 blockers, 1 epics",
+			}))
+
+			Expect(mutations()).To(BeEmpty())
+			Expect(fake.Stories()).To(HaveLen(1))
+			Expect(fake.Epics()).To(BeEmpty())
+
+			Expect(log).To(gbytes.Say(`\+ create epic "Shield"\n`))
+			Expect(log).To(gbytes.Say(`\+ create bug "build it" \(unstarted, before #\d+\)\n`))
+			Expect(log).To(gbytes.Say(`\+ create chore "deploy it" \(after new story 1\)\n`))
+			Expect(log).To(gbytes.Say(`~ block new story 2 by new story 1\n`))
+			Expect(log).To(gbytes.Say(`Plan: 2 to create, 0 to update, 0 to delete, 0 labels, 0 comments, 1 blockers, 1 epics.`))
+			Expect(log).NotTo(gbytes.Say("Story created"))
+
+			contents, err := ioutil.ReadFile(filepath.Join(sources, "plan.json"))
+			Expect(err).NotTo(HaveOccurred())
+
+			var plan out.Plan
+			Expect(json.Unmarshal(contents, &plan)).To(Succeed())
+			Expect(plan.Changes).To(HaveLen(4))
+			Expect(plan.Changes[1].Action).To(Equal(out.ActionCreate))
+			Expect(plan.Changes[1].StoryID).To(Equal(-1))
+			Expect(plan.Changes[1].Story.Name).To(Equal("build it"))
+			Expect(plan.Changes[3]).To(Equal(out.Change{Action: out.ActionBlock, StoryID: -2, Blocker: "#-1"}))
+		})
+	})
+
+	It("writes no plan file unless asked, as Concourse discards the sources", func() {
+		err := ioutil.WriteFile(filepath.Join(sources, "stories.yml"), []byte("stories:\n- name: vent it\n"), os.ModePerm)
+		Expect(err).NotTo(HaveOccurred())
+
+		_, err = out.Run(context.Background(), out.OutRequest{
+			Params: out.Params{ManifestPath: "stories.yml", DryRun: true},
+		}, sources, env)
+		Expect(err).NotTo(HaveOccurred())
+
+		_, err = os.Stat(filepath.Join(sources, "plan.json"))
+		Expect(os.IsNotExist(err)).To(BeTrue())
+	})
+
+	It("diffs the estimate and labels an update changes", func() {
+		one := 1.0
+		story := fake.AddStory(tracker.Story{
+			Name:     "vent it",
+			Estimate: &one,
+			Labels:   []tracker.Label{{Name: "exhaust"}, {Name: "port"}},
+		})
+
+		two := 2.0
+		plan := out.NewPlan(env.Client)
+		_, err := plan.UpdateStory(tracker.Story{
+			ID:       story.ID,
+			Estimate: &two,
+			Labels:   []tracker.Label{{Name: "exhaust"}, {Name: "shield"}},
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(plan.String()).To(ContainSubstring(
+			"    - estimate: 1\n    + estimate: 2\n" +
+				"    - label: port\n    + label: shield\n",
+		))
+	})
+
+	Context("with findings that already have stories", func() {
+		BeforeEach(func() {
+			err := ioutil.WriteFile(filepath.Join(sources, "junit.xml"), []byte(Fixture("junit.xml")), os.ModePerm)
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = out.Run(context.Background(), out.OutRequest{
+				Params: out.Params{ContentPath: "junit.xml", Format: "junit"},
+			}, sources, env)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("diffs the updates it would make and writes the plan where asked", func() {
+			before := len(mutations())
+			log = gbytes.NewBuffer()
+			env.Log = log
+
+			Expect(os.Mkdir(filepath.Join(sources, "plans"), 0755)).To(Succeed())
+
+			_, err := out.Run(context.Background(), out.OutRequest{
+				Params: out.Params{
+					ContentPath: "junit.xml",
+					Format:      "junit",
+					DryRun:      true,
+					PlanFile:    "plans/junit.json",
+				},
+			}, sources, env)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(mutations()).To(HaveLen(before))
+
+			story := fake.Stories()[0]
+			Expect(fake.Comments(story.ID)).To(BeEmpty())
+
+			Expect(log).To(gbytes.Say(`~ update #%d "%s"\n`, story.ID, story.Name))
+			Expect(log).To(gbytes.Say(`    - <!-- tracker-story-resource .*\n`))
+			Expect(log).To(gbytes.Say(`    \+ <!-- tracker-story-resource .*\n`))
+			Expect(log).To(gbytes.Say(`~ comment on #%d\n`, story.ID))
+
+			_, err = os.Stat(filepath.Join(sources, "plans", "junit.json"))
+			Expect(err).NotTo(HaveOccurred())
+		})
+	})
+})
@@ -0,0 +1,152 @@
+package out
+
+import (
+	"errors"
+
+	"github.com/XenoPhex/go-tracker"
+	"github.com/cjcjameson/tracker-story-resource"
+)
+
+type Position struct {
+	TopOfBacklog         bool `json:"top_of_backlog"`
+	BottomOfBacklog      bool `json:"bottom_of_backlog"`
+	BeforeID             int  `json:"before_id"`
+	AfterID              int  `json:"after_id"`
+	IntoCurrentIteration bool `json:"into_current_iteration"`
+}
+
+func (p Position) Validate() error {
+	chosen := 0
+	for _, set := range []bool{
+		p.TopOfBacklog,
+		p.BottomOfBacklog,
+		p.BeforeID != 0,
+		p.AfterID != 0,
+		p.IntoCurrentIteration,
+	} {
+		if set {
+			chosen++
+		}
+	}
+
+	if chosen > 1 {
+		return errors.New("only one position may be given")
+	}
+
+	return nil
+}
+
+// Place sets the state and neighbour of a story that is about to be created
+// so that Tracker puts it where the position asks. Stories without a position
+// are left in the icebox.
+func (p Position) Place(story tracker.Story, client resource.Reader) (tracker.Story, error) {
+	if err := p.Validate(); err != nil {
+		return story, err
+	}
+
+	switch {
+	case p.TopOfBacklog:
+		story.State = tracker.StoryStateUnstarted
+
+		first, err := firstWithState(client, tracker.StoryStateUnstarted)
+		if err != nil {
+			return story, err
+		}
+
+		story.BeforeID = first
+	case p.BottomOfBacklog:
+		story.State = tracker.StoryStateUnstarted
+
+		last, err := lastWithState(client, tracker.StoryStateUnstarted)
+		if err != nil {
+			return story, err
+		}
+
+		story.AfterID = last
+	case p.BeforeID != 0:
+		// Tracker moves the story into the panel of its neighbour.
+		story.State = ""
+		story.BeforeID = p.BeforeID
+	case p.AfterID != 0:
+		story.State = ""
+		story.AfterID = p.AfterID
+	case p.IntoCurrentIteration:
+		project, err := client.Project()
+		if err != nil {
+			return story, err
+		}
+
+		if !project.AutomaticPlanning {
+			story.State = tracker.StoryStatePlanned
+			break
+		}
+
+		// Tracker refuses planned stories when it plans iterations itself,
+		// so the story goes ahead of the current iteration's first unstarted
+		// story, which is the backlog's first.
+		story.State = tracker.StoryStateUnstarted
+
+		first, err := firstWithState(client, tracker.StoryStateUnstarted)
+		if err != nil {
+			return story, err
+		}
+
+		story.BeforeID = first
+	}
+
+	return story, nil
+}
+
+// Next is the position of the story created after the given one in the same
+// put. Stories placed before the first story or after a given one follow the
+// story created before them, so that they keep the manifest's order.
+func (p Position) Next(created tracker.Story) Position {
+	placedFirst := p.IntoCurrentIteration && created.State == tracker.StoryStateUnstarted
+	if p.TopOfBacklog || p.AfterID != 0 || placedFirst {
+		return Position{AfterID: created.ID}
+	}
+
+	return p
+}
+
+func firstWithState(client resource.Reader, state tracker.StoryState) (int, error) {
+	stories, _, err := client.Stories(tracker.StoriesQuery{
+		State: state,
+		Limit: 1,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	if len(stories) == 0 {
+		return 0, nil
+	}
+
+	return stories[0].ID, nil
+}
+
+func lastWithState(client resource.Reader, state tracker.StoryState) (int, error) {
+	query := tracker.StoriesQuery{
+		State: state,
+		Limit: 1,
+	}
+
+	stories, pagination, err := client.Stories(query)
+	if err != nil {
+		return 0, err
+	}
+
+	if pagination.Total > 1 {
+		query.Offset = pagination.Total - 1
+		stories, _, err = client.Stories(query)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	if len(stories) == 0 {
+		return 0, nil
+	}
+
+	return stories[len(stories)-1].ID, nil
+}
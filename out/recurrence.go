@@ -0,0 +1,118 @@
+package out
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/XenoPhex/go-tracker"
+)
+
+const flakyLabel = "flaky"
+
+type Flaky struct {
+	Occurrences int    `json:"occurrences"`
+	Window      string `json:"window"`
+	Label       string `json:"label"`
+}
+
+// Recurrence is another occurrence of a finding that already has a story.
+type Recurrence struct {
+	Story       tracker.Story
+	Marker      Marker
+	Occurrences int
+}
+
+// Recur records an occurrence at the given time in the marker of the story
+// filed for a finding. Stories filed before occurrences were recorded count
+// their creation as the first one. Only the occurrences within the window are
+// kept, or just the latest without one, so that the marker stays small
+// however often the finding recurs.
+func Recur(story tracker.Story, at time.Time, window time.Duration) Recurrence {
+	marker, _ := ParseMarker(story.Description)
+
+	if marker.Total() == 0 && story.CreatedAt != nil {
+		marker.Occurrences = append(marker.Occurrences, *story.CreatedAt)
+	}
+
+	marker.Count = marker.Total() + 1
+	marker.Occurrences = append(marker.Occurrences, at.UTC())
+	marker.PassedAt = nil
+
+	if window > 0 {
+		var kept []time.Time
+		for _, occurrence := range marker.Occurrences {
+			if !occurrence.Before(at.Add(-window)) {
+				kept = append(kept, occurrence)
+			}
+		}
+
+		marker.Occurrences = kept
+	} else {
+		marker.Occurrences = marker.Occurrences[len(marker.Occurrences)-1:]
+	}
+
+	story.Description = WithMarker(story.Description, marker)
+
+	return Recurrence{
+		Story:       story,
+		Marker:      marker,
+		Occurrences: marker.Count,
+	}
+}
+
+func (r Recurrence) Comment(buildURL string) string {
+	if buildURL == "" {
+		return fmt.Sprintf("Failed again (occurrence %d).", r.Occurrences)
+	}
+
+	return fmt.Sprintf("Failed again in %s (occurrence %d).", buildURL, r.Occurrences)
+}
+
+func (f Flaky) LabelName() string {
+	if f.Label == "" {
+		return flakyLabel
+	}
+
+	return f.Label
+}
+
+// WindowDuration is how far back occurrences count towards flakiness. Zero means
+// every occurrence counts.
+func (f Flaky) WindowDuration() (time.Duration, error) {
+	if f.Window == "" {
+		return 0, nil
+	}
+
+	window, err := time.ParseDuration(f.Window)
+	if err != nil {
+		return 0, fmt.Errorf("invalid flaky window: %s", err)
+	}
+
+	return window, nil
+}
+
+// Reached reports whether the finding occurred often enough within the window
+// to be considered flaky. Without a window every occurrence counts.
+func (f Flaky) Reached(marker Marker, now time.Time) (bool, error) {
+	if f.Occurrences <= 0 {
+		return false, nil
+	}
+
+	window, err := f.WindowDuration()
+	if err != nil {
+		return false, err
+	}
+
+	if window == 0 {
+		return marker.Total() >= f.Occurrences, nil
+	}
+
+	count := 0
+	for _, at := range marker.Occurrences {
+		if !at.Before(now.Add(-window)) {
+			count++
+		}
+	}
+
+	return count >= f.Occurrences, nil
+}
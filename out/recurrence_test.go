@@ -0,0 +1,104 @@
+package out_test
+
+import (
+	"time"
+
+	"github.com/XenoPhex/go-tracker"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/cjcjameson/tracker-story-resource/out"
+)
+
+var _ = Describe("Recurring findings", func() {
+	var now time.Time
+
+	BeforeEach(func() {
+		now = time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	})
+
+	It("records each occurrence in the story's marker", func() {
+		story := tracker.Story{
+			Description: out.WithMarker("it broke", out.Marker{
+				Fingerprint: "junit:suite/test",
+				Occurrences: []time.Time{now.Add(-time.Hour)},
+			}),
+		}
+
+		recurrence := out.Recur(story, now, 24*time.Hour)
+		Expect(recurrence.Occurrences).To(Equal(2))
+
+		marker, found := out.ParseMarker(recurrence.Story.Description)
+		Expect(found).To(BeTrue())
+		Expect(marker.Count).To(Equal(2))
+		Expect(marker.Occurrences).To(Equal([]time.Time{now.Add(-time.Hour), now}))
+	})
+
+	It("only keeps the occurrences inside the window", func() {
+		story := tracker.Story{
+			Description: out.WithMarker("it broke", out.Marker{
+				Fingerprint: "junit:suite/test",
+				Count:       40,
+				Occurrences: []time.Time{now.Add(-48 * time.Hour), now.Add(-time.Hour)},
+			}),
+		}
+
+		recurrence := out.Recur(story, now, 24*time.Hour)
+		Expect(recurrence.Occurrences).To(Equal(41))
+		Expect(recurrence.Marker.Occurrences).To(Equal([]time.Time{now.Add(-time.Hour), now}))
+
+		recurrence = out.Recur(story, now, 0)
+		Expect(recurrence.Occurrences).To(Equal(41))
+		Expect(recurrence.Marker.Occurrences).To(Equal([]time.Time{now}))
+	})
+
+	It("counts the occurrences of markers written before the count was kept", func() {
+		story := tracker.Story{
+			Description: out.WithMarker("it broke", out.Marker{
+				Fingerprint: "junit:suite/test",
+				Occurrences: []time.Time{now.Add(-2 * time.Hour), now.Add(-time.Hour)},
+			}),
+		}
+
+		Expect(out.Recur(story, now, 0).Occurrences).To(Equal(3))
+	})
+
+	It("comments with the build that saw the failure", func() {
+		recurrence := out.Recurrence{Occurrences: 3}
+		Expect(recurrence.Comment("http://ci/builds/1")).To(Equal("Failed again in http://ci/builds/1 (occurrence 3)."))
+		Expect(recurrence.Comment("")).To(Equal("Failed again (occurrence 3)."))
+	})
+
+	Describe("flakiness", func() {
+		marker := func(ago ...time.Duration) out.Marker {
+			m := out.Marker{}
+			for _, d := range ago {
+				m.Occurrences = append(m.Occurrences, now.Add(-d))
+			}
+			return m
+		}
+
+		It("is reached with enough occurrences inside the window", func() {
+			flaky := out.Flaky{Occurrences: 3, Window: "24h"}
+
+			reached, err := flaky.Reached(marker(48*time.Hour, 2*time.Hour, time.Hour, 0), now)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(reached).To(BeTrue())
+
+			reached, err = flaky.Reached(marker(48*time.Hour, 30*time.Hour, time.Hour, 0), now)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(reached).To(BeFalse())
+		})
+
+		It("counts every occurrence without a window", func() {
+			reached, err := out.Flaky{Occurrences: 3}.Reached(out.Marker{Count: 3, Occurrences: []time.Time{now}}, now)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(reached).To(BeTrue())
+		})
+
+		It("rejects invalid windows", func() {
+			_, err := out.Flaky{Occurrences: 3, Window: "a week"}.Reached(marker(0), now)
+			Expect(err).To(MatchError(ContainSubstring("invalid flaky window")))
+		})
+	})
+})
@@ -0,0 +1,58 @@
+package out
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/XenoPhex/go-tracker"
+	"github.com/cjcjameson/tracker-story-resource"
+)
+
+type Release struct {
+	Repo     string `json:"repo"`
+	Tag      string `json:"tag"`
+	Deadline string `json:"deadline"`
+}
+
+func (r Release) Story(tag string) (tracker.Story, error) {
+	story := tracker.Story{
+		Name: tag,
+		Type: tracker.StoryTypeRelease,
+	}
+
+	if r.Deadline != "" {
+		deadline, err := parseDeadline(r.Deadline)
+		if err != nil {
+			return tracker.Story{}, err
+		}
+
+		story.Deadline = &deadline
+	}
+
+	return story, nil
+}
+
+// ReleasePosition puts a release marker right after the last delivered story,
+// or at the top of the backlog when nothing is waiting for acceptance.
+func ReleasePosition(client resource.Reader) (Position, error) {
+	last, err := lastWithState(client, tracker.StoryStateDelivered)
+	if err != nil {
+		return Position{}, err
+	}
+
+	if last == 0 {
+		return Position{TopOfBacklog: true}, nil
+	}
+
+	return Position{AfterID: last}, nil
+}
+
+func parseDeadline(deadline string) (time.Time, error) {
+	for _, layout := range []string{time.RFC3339, "2006-01-02"} {
+		if t, err := time.Parse(layout, deadline); err == nil {
+			return t, nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("invalid deadline: %s", deadline)
+}
@@ -0,0 +1,45 @@
+package out
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/XenoPhex/go-tracker"
+)
+
+type Resolve struct {
+	Finish            bool `json:"finish"`
+	DeleteUnscheduled bool `json:"delete_unscheduled"`
+}
+
+// Resolvable reports whether a story filed for a finding should be resolved
+// now that the check passes: it must not be finished or delivered already,
+// and must not have been seen passing since it last failed.
+func Resolvable(story tracker.Story) bool {
+	switch story.State {
+	case tracker.StoryStateFinished, tracker.StoryStateDelivered, tracker.StoryStateAccepted:
+		return false
+	}
+
+	marker, found := ParseMarker(story.Description)
+	return found && marker.PassedAt == nil
+}
+
+// Pass records in the story's marker when its check was seen passing.
+func Pass(story tracker.Story, at time.Time) tracker.Story {
+	marker, _ := ParseMarker(story.Description)
+
+	at = at.UTC()
+	marker.PassedAt = &at
+
+	story.Description = WithMarker(story.Description, marker)
+	return story
+}
+
+func PassedComment(buildURL string) string {
+	if buildURL == "" {
+		return "Passed again."
+	}
+
+	return fmt.Sprintf("Passed in %s.", buildURL)
+}
@@ -0,0 +1,1119 @@
+package out
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/XenoPhex/go-tracker"
+	"github.com/cjcjameson/tracker-story-resource"
+)
+
+type run struct {
+	ctx     context.Context
+	env     resource.Env
+	client  resource.Client
+	writer  Writer
+	plan    *Plan
+	source  resource.Source
+	sources string
+	params  Params
+}
+
+// Run puts the request: it creates the stories, release marker or chores the
+// params ask for, with paths relative to the sources directory. A dry run
+// logs and writes the plan of what it would change instead.
+func Run(ctx context.Context, request OutRequest, sources string, env resource.Env) (OutResponse, error) {
+	client, err := env.ProjectClient(ctx, request.Source)
+	if err != nil {
+		return OutResponse{}, err
+	}
+
+	r := run{
+		ctx:     ctx,
+		env:     env,
+		client:  client,
+		writer:  client,
+		source:  request.Source,
+		sources: sources,
+		params:  request.Params,
+	}
+
+	if request.Params.DryRun {
+		r.plan = NewPlan(client)
+		r.writer = r.plan
+
+		// the plan says what would happen, where the log would say what did
+		r.env.Log = nil
+	}
+
+	metadata, err := r.put()
+	if err != nil {
+		return OutResponse{}, err
+	}
+
+	if r.plan != nil {
+		env.Logf("%s", r.plan)
+		metadata = append(metadata, resource.MetadataPair{Name: "plan", Value: r.plan.Summary()})
+
+		if err := r.writePlan(r.plan); err != nil {
+			return OutResponse{}, fmt.Errorf("writing plan: %s", err)
+		}
+	}
+
+	return OutResponse{
+		Version: resource.Version{
+			Time: env.Time(),
+		},
+		Metadata: metadata,
+	}, nil
+}
+
+// writePlan writes the plan to the plan file, if there is one. Concourse
+// discards a put's sources directory, so only the CLI asks for one.
+func (r run) writePlan(plan *Plan) error {
+	path := r.params.PlanFile
+	if path == "" {
+		return nil
+	}
+
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(r.sources, path)
+	}
+
+	contents, err := json.MarshalIndent(plan, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(path, append(contents, '\n'), 0644)
+}
+
+func (r run) put() ([]resource.MetadataPair, error) {
+	params := r.params
+
+	if params.Release != nil {
+		return nil, r.createRelease(*params.Release)
+	}
+
+	if params.Todos != nil {
+		return nil, r.syncTodos(*params.Todos)
+	}
+
+	if params.Sync != nil {
+		return r.syncStories(*params.Sync)
+	}
+
+	if params.Import != nil {
+		return r.importStories(*params.Import)
+	}
+
+	if params.Mirror != nil {
+		return r.mirrorStories(*params.Mirror)
+	}
+
+	if params.Format != "" && params.Format != "text" {
+		if params.ContentPath == "" {
+			return nil, errors.New("no content file specified")
+		}
+
+		return nil, r.fileFindings(filepath.Join(r.sources, params.ContentPath))
+	}
+
+	var manifest Manifest
+	switch {
+	case params.ManifestPath != "":
+		var err error
+		manifest, err = ReadManifest(filepath.Join(r.sources, params.ManifestPath))
+		if err != nil {
+			return nil, fmt.Errorf("reading manifest: %s", err)
+		}
+	case params.ContentPath != "":
+		contents, err := ioutil.ReadFile(filepath.Join(r.sources, params.ContentPath))
+		if err != nil {
+			return nil, fmt.Errorf("reading content file: %s", err)
+		}
+
+		manifest = Manifest{
+			Stories: []ManifestStory{
+				{Name: string(contents)},
+			},
+		}
+	default:
+		return nil, errors.New("no content file specified")
+	}
+
+	return nil, r.createStories(manifest)
+}
+
+func (r run) createStories(manifest Manifest) error {
+	entries, err := manifest.Ordered()
+	if err != nil {
+		return fmt.Errorf("ordering stories: %s", err)
+	}
+
+	stories, err := r.resolveStories(entries)
+	if err != nil {
+		return err
+	}
+
+	position := r.params.Position
+	created := map[string]int{}
+	createdIDs := make([]int, len(stories))
+	for i, story := range stories {
+		if err := r.ctx.Err(); err != nil {
+			return err
+		}
+
+		story, err = position.Place(story, r.client)
+		if err != nil {
+			return fmt.Errorf("positioning story: %s", err)
+		}
+
+		story, err = r.writer.CreateStory(story)
+		if err != nil {
+			return fmt.Errorf("creating story: %s", err)
+		}
+
+		position = position.Next(story)
+
+		r.env.Logf("Story created with ID: %d Name: %s\n", story.ID, story.Name)
+
+		createdIDs[i] = story.ID
+		if entries[i].ID != "" {
+			created[entries[i].ID] = story.ID
+		}
+	}
+
+	for i, entry := range entries {
+		blockers, err := entry.Blockers(created)
+		if err != nil {
+			return fmt.Errorf("resolving blockers: %s", err)
+		}
+
+		for _, blocker := range blockers {
+			_, err := r.writer.CreateBlocker(createdIDs[i], tracker.Blocker{
+				Description: fmt.Sprintf("#%d", blocker),
+			})
+			if err != nil {
+				return fmt.Errorf("creating blocker: %s", err)
+			}
+
+			r.env.Logf("Story %d blocked by %d\n", createdIDs[i], blocker)
+		}
+	}
+
+	return nil
+}
+
+func (r run) resolveStories(entries []ManifestStory) ([]tracker.Story, error) {
+	epics := NewEpicLabels(r.client, r.writer)
+
+	var stories []tracker.Story
+	for _, entry := range entries {
+		story := entry.Story()
+
+		if entry.Epic != "" {
+			label, err := epics.Label(entry.Epic)
+			if err != nil {
+				return nil, fmt.Errorf("finding epic: %s", err)
+			}
+
+			story.Labels = append(story.Labels, label)
+		}
+
+		stories = append(stories, story)
+	}
+
+	return stories, nil
+}
+
+func (r run) createRelease(release Release) error {
+	repo := filepath.Join(r.sources, release.Repo)
+
+	tag := release.Tag
+	if tag == "" {
+		var err error
+		tag, err = resource.LatestTag(repo, "HEAD")
+		if err != nil {
+			return fmt.Errorf("finding release tag: %s", err)
+		}
+
+		if tag == "" {
+			return fmt.Errorf("finding release tag: no tags found in %s", release.Repo)
+		}
+	}
+
+	previous, err := resource.PreviousTag(repo, tag)
+	if err != nil {
+		return fmt.Errorf("finding previous release tag: %s", err)
+	}
+
+	messages, err := resource.CommitMessages(repo, previous, tag)
+	if err != nil {
+		return fmt.Errorf("reading commits: %s", err)
+	}
+
+	story, err := release.Story(tag)
+	if err != nil {
+		return fmt.Errorf("building release: %s", err)
+	}
+
+	position, err := ReleasePosition(r.client)
+	if err != nil {
+		return fmt.Errorf("finding last delivered story: %s", err)
+	}
+
+	story, err = position.Place(story, r.client)
+	if err != nil {
+		return fmt.Errorf("positioning release: %s", err)
+	}
+
+	story, err = r.writer.CreateStory(story)
+	if err != nil {
+		return fmt.Errorf("creating release: %s", err)
+	}
+
+	r.env.Logf("Release created with ID: %d Name: %s\n", story.ID, story.Name)
+
+	for _, id := range resource.StoryIDs(messages) {
+		if err := r.ctx.Err(); err != nil {
+			return err
+		}
+
+		if _, err := r.writer.AddStoryLabel(id, tracker.Label{Name: tag}); err != nil {
+			r.env.Logf("could not label story %d: %s\n", id, err)
+			continue
+		}
+
+		r.env.Logf("Story %d labeled %s\n", id, tag)
+	}
+
+	return nil
+}
+
+func (r run) fileFindings(contentPath string) error {
+	contents, err := ioutil.ReadFile(contentPath)
+	if err != nil {
+		return fmt.Errorf("reading content file: %s", err)
+	}
+
+	report, err := r.params.ParseReport(contents)
+	if err != nil {
+		return fmt.Errorf("parsing content file: %s", err)
+	}
+
+	open, err := OpenFindings(r.client)
+	if err != nil {
+		return fmt.Errorf("fetching open stories: %s", err)
+	}
+
+	now := r.env.Time()
+
+	failed := map[string]bool{}
+
+	for _, finding := range report.Findings {
+		if err := r.ctx.Err(); err != nil {
+			return err
+		}
+
+		failed[finding.Fingerprint] = true
+
+		if existing, found := open[finding.Fingerprint]; found {
+			// standing findings only recur once they were seen resolved
+			marker, _ := ParseMarker(existing.Description)
+			if finding.Standing && marker.PassedAt == nil {
+				r.env.Logf("Story already filed for %s with ID: %d\n", finding.Fingerprint, existing.ID)
+			} else {
+				open[finding.Fingerprint], err = r.recur(existing, now)
+				if err != nil {
+					return err
+				}
+			}
+
+			if len(finding.Tasks) > 0 {
+				open[finding.Fingerprint], err = r.regroup(open[finding.Fingerprint], finding)
+				if err != nil {
+					return err
+				}
+			}
+
+			if err := r.replace(existing, finding, open); err != nil {
+				return err
+			}
+			continue
+		}
+
+		story := finding.Story()
+		story.Description = WithMarker(story.Description, Marker{
+			Fingerprint: finding.Fingerprint,
+			Count:       1,
+			Occurrences: []time.Time{now.UTC()},
+		})
+
+		story, err := r.params.FindingPosition().Place(story, r.client)
+		if err != nil {
+			return fmt.Errorf("positioning story: %s", err)
+		}
+
+		story, err = r.writer.CreateStory(story)
+		if err != nil {
+			return fmt.Errorf("creating story: %s", err)
+		}
+
+		open[finding.Fingerprint] = story
+
+		r.env.Logf("Story created with ID: %d Name: %s\n", story.ID, story.Name)
+
+		if err := r.replace(story, finding, open); err != nil {
+			return err
+		}
+	}
+
+	for _, resolution := range report.Resolved {
+		existing, found := open[resolution.Fingerprint]
+		if !found || failed[resolution.Fingerprint] || !Resolvable(existing) {
+			continue
+		}
+
+		at := resolution.At
+		if at.IsZero() {
+			at = now
+		}
+
+		_, err := r.writer.UpdateStory(tracker.Story{
+			ID:          existing.ID,
+			Description: Pass(existing, at).Description,
+		})
+		if err != nil {
+			return fmt.Errorf("resolving story: %s", err)
+		}
+
+		_, err = r.writer.CreateComment(existing.ID, tracker.Comment{
+			Text: ResolvedComment(at),
+		})
+		if err != nil {
+			return fmt.Errorf("commenting on story: %s", err)
+		}
+
+		r.env.Logf("Story %d resolved\n", existing.ID)
+	}
+
+	// Passing checks are always commented on; resolve only decides whether
+	// their stories are also finished or deleted.
+	var resolve Resolve
+	if r.params.Resolve != nil {
+		resolve = *r.params.Resolve
+	}
+
+	for _, fingerprint := range report.Passed {
+		existing, found := open[fingerprint]
+		if !found || failed[fingerprint] || !Resolvable(existing) {
+			continue
+		}
+
+		if err := r.resolve(existing, resolve, now); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// regroup keeps the story of a grouped finding in step with the locations the
+// tool reports now: its name and description count them and it has a task for
+// each. Tasks of locations that are still reported are kept, so that ticking
+// them off survives the next run.
+func (r run) regroup(existing tracker.Story, finding Finding) (tracker.Story, error) {
+	changed := false
+
+	marker, _ := ParseMarker(existing.Description)
+	description := WithMarker(finding.Description, marker)
+	if existing.Name != finding.Name || existing.Description != description {
+		updated, err := r.writer.UpdateStory(tracker.Story{
+			ID:          existing.ID,
+			Name:        finding.Name,
+			Description: description,
+		})
+		if err != nil {
+			return existing, fmt.Errorf("updating grouped story: %s", err)
+		}
+
+		existing = updated
+		changed = true
+	}
+
+	tasks, err := r.client.StoryTasks(existing.ID)
+	if err != nil {
+		return existing, fmt.Errorf("fetching tasks: %s", err)
+	}
+
+	wanted := map[string]int{}
+	for _, task := range finding.Tasks {
+		wanted[task]++
+	}
+
+	for _, task := range tasks {
+		if wanted[task.Description] > 0 {
+			wanted[task.Description]--
+			continue
+		}
+
+		if err := r.writer.DeleteTask(existing.ID, task.ID); err != nil {
+			return existing, fmt.Errorf("removing task: %s", err)
+		}
+		changed = true
+	}
+
+	for _, task := range finding.Tasks {
+		if wanted[task] == 0 {
+			continue
+		}
+		wanted[task]--
+
+		if _, err := r.writer.CreateTask(existing.ID, tracker.Task{Description: task}); err != nil {
+			return existing, fmt.Errorf("adding task: %s", err)
+		}
+		changed = true
+	}
+
+	if changed {
+		r.env.Logf("Story %d now has %d locations\n", existing.ID, len(finding.Tasks))
+	}
+
+	return existing, nil
+}
+
+// replace closes the open stories of the findings a finding takes over,
+// pointing them to the story that replaces them.
+func (r run) replace(story tracker.Story, finding Finding, open map[string]tracker.Story) error {
+	for _, fingerprint := range finding.Replaces {
+		replaced, found := open[fingerprint]
+		if !found || replaced.ID == story.ID {
+			continue
+		}
+
+		_, err := r.writer.UpdateStory(tracker.Story{
+			ID:    replaced.ID,
+			State: tracker.StoryStateAccepted,
+		})
+		if err != nil {
+			return fmt.Errorf("closing replaced story: %s", err)
+		}
+
+		_, err = r.writer.CreateComment(replaced.ID, tracker.Comment{
+			Text: fmt.Sprintf("Replaced by #%d.", story.ID),
+		})
+		if err != nil {
+			return fmt.Errorf("commenting on story: %s", err)
+		}
+
+		delete(open, fingerprint)
+
+		r.env.Logf("Story %d replaced by %d\n", replaced.ID, story.ID)
+	}
+
+	return nil
+}
+
+func (r run) resolve(existing tracker.Story, params Resolve, now time.Time) error {
+	if params.DeleteUnscheduled && existing.State == tracker.StoryStateUnscheduled {
+		if err := r.writer.DeleteStory(existing.ID); err != nil {
+			return fmt.Errorf("deleting story: %s", err)
+		}
+
+		r.env.Logf("Story %d passed and was deleted\n", existing.ID)
+		return nil
+	}
+
+	update := tracker.Story{
+		ID:          existing.ID,
+		Description: Pass(existing, now).Description,
+	}
+
+	if params.Finish {
+		update.State = tracker.StoryStateFinished
+	}
+
+	if _, err := r.writer.UpdateStory(update); err != nil {
+		return fmt.Errorf("resolving story: %s", err)
+	}
+
+	_, err := r.writer.CreateComment(existing.ID, tracker.Comment{
+		Text: PassedComment(resource.BuildURL()),
+	})
+	if err != nil {
+		return fmt.Errorf("commenting on story: %s", err)
+	}
+
+	if params.Finish {
+		r.env.Logf("Story %d passed and was finished\n", existing.ID)
+	} else {
+		r.env.Logf("Story %d passed\n", existing.ID)
+	}
+
+	return nil
+}
+
+func (r run) recur(existing tracker.Story, now time.Time) (tracker.Story, error) {
+	var window time.Duration
+	if r.params.Flaky != nil {
+		var err error
+		window, err = r.params.Flaky.WindowDuration()
+		if err != nil {
+			return existing, fmt.Errorf("checking for flakiness: %s", err)
+		}
+	}
+
+	recurrence := Recur(existing, now, window)
+
+	updated, err := r.writer.UpdateStory(tracker.Story{
+		ID:          existing.ID,
+		Description: recurrence.Story.Description,
+	})
+	if err != nil {
+		return existing, fmt.Errorf("recording occurrence: %s", err)
+	}
+
+	_, err = r.writer.CreateComment(existing.ID, tracker.Comment{
+		Text: recurrence.Comment(resource.BuildURL()),
+	})
+	if err != nil {
+		return existing, fmt.Errorf("commenting on story: %s", err)
+	}
+
+	r.env.Logf("Story %d failed again (occurrence %d)\n", existing.ID, recurrence.Occurrences)
+
+	flaky := r.params.Flaky
+	if flaky == nil {
+		return updated, nil
+	}
+
+	reached, err := flaky.Reached(recurrence.Marker, now)
+	if err != nil {
+		return updated, fmt.Errorf("checking for flakiness: %s", err)
+	}
+
+	if reached && !resource.HasLabel(updated, flaky.LabelName()) {
+		if _, err := r.writer.AddStoryLabel(existing.ID, tracker.Label{Name: flaky.LabelName()}); err != nil {
+			return updated, fmt.Errorf("labeling story: %s", err)
+		}
+
+		r.env.Logf("Story %d labeled %s\n", existing.ID, flaky.LabelName())
+	}
+
+	return updated, nil
+}
+
+func (r run) syncTodos(todos Todos) error {
+	findings, err := todos.Findings(filepath.Join(r.sources, todos.Repo))
+	if err != nil {
+		return fmt.Errorf("scanning for markers: %s", err)
+	}
+
+	open, err := OpenFindings(r.client)
+	if err != nil {
+		return fmt.Errorf("fetching open stories: %s", err)
+	}
+
+	present := map[string]bool{}
+
+	for _, finding := range findings {
+		if err := r.ctx.Err(); err != nil {
+			return err
+		}
+
+		present[finding.Fingerprint] = true
+
+		story := finding.Story()
+
+		if existing, found := open[finding.Fingerprint]; found {
+			if existing.Description == story.Description {
+				continue
+			}
+
+			// keep the location and permalink current as the code moves
+			_, err := r.writer.UpdateStory(tracker.Story{
+				ID:          existing.ID,
+				Description: story.Description,
+			})
+			if err != nil {
+				return fmt.Errorf("updating story: %s", err)
+			}
+
+			r.env.Logf("Story %d moved to %s\n", existing.ID, finding.Fingerprint)
+			continue
+		}
+
+		story, err := r.params.Position.Place(story, r.client)
+		if err != nil {
+			return fmt.Errorf("positioning story: %s", err)
+		}
+
+		story, err = r.writer.CreateStory(story)
+		if err != nil {
+			return fmt.Errorf("creating story: %s", err)
+		}
+
+		r.env.Logf("Story created with ID: %d Name: %s\n", story.ID, story.Name)
+	}
+
+	var gone []string
+	for fingerprint := range open {
+		if strings.HasPrefix(fingerprint, todos.FingerprintPrefix()) && !present[fingerprint] {
+			gone = append(gone, fingerprint)
+		}
+	}
+	sort.Strings(gone)
+
+	for _, fingerprint := range gone {
+		existing := open[fingerprint]
+
+		_, err := r.writer.UpdateStory(tracker.Story{
+			ID:    existing.ID,
+			State: tracker.StoryStateAccepted,
+		})
+		if err != nil {
+			return fmt.Errorf("closing story: %s", err)
+		}
+
+		_, err = r.writer.CreateComment(existing.ID, tracker.Comment{
+			Text: "The marker was removed from the code.",
+		})
+		if err != nil {
+			return fmt.Errorf("commenting on story: %s", err)
+		}
+
+		r.env.Logf("Story %d closed\n", existing.ID)
+	}
+
+	return nil
+}
+
+func (r run) syncStories(sync Sync) ([]resource.MetadataPair, error) {
+	if err := sync.Validate(); err != nil {
+		return nil, err
+	}
+
+	manifest, err := ReadManifest(filepath.Join(r.sources, sync.Manifest))
+	if err != nil {
+		return nil, fmt.Errorf("reading manifest: %s", err)
+	}
+
+	if len(manifest.Stories) == 0 && !sync.AllowEmpty {
+		return nil, errors.New("refusing to prune every owned story: the manifest has no stories, set allow_empty if it means to")
+	}
+
+	entries, err := manifest.Ordered()
+	if err != nil {
+		return nil, fmt.Errorf("ordering stories: %s", err)
+	}
+
+	wanted := map[string]bool{}
+	named := map[string]string{}
+	for _, entry := range entries {
+		fingerprint := sync.Fingerprint(entry)
+		if wanted[fingerprint] {
+			return nil, fmt.Errorf("duplicate story in manifest: %s", fingerprint)
+		}
+
+		wanted[fingerprint] = true
+		named[entry.Name] = fingerprint
+	}
+
+	owned, err := resource.AllStories(r.client, tracker.StoriesQuery{Label: sync.Owner})
+	if err != nil {
+		return nil, fmt.Errorf("fetching owned stories: %s", err)
+	}
+
+	existing := map[string]tracker.Story{}
+	var unmarked []tracker.Story
+	for _, story := range owned {
+		marker, found := ParseMarker(story.Description)
+		if _, duplicate := existing[marker.Fingerprint]; found && wanted[marker.Fingerprint] && !duplicate {
+			existing[marker.Fingerprint] = story
+			continue
+		}
+
+		unmarked = append(unmarked, story)
+	}
+
+	// stories labeled by hand are adopted by name; every other owned story
+	// that does not match a manifest story is pruned
+	var prune []tracker.Story
+	for _, story := range unmarked {
+		if _, marked := ParseMarker(story.Description); !marked {
+			fingerprint, found := named[story.Name]
+			if _, taken := existing[fingerprint]; found && !taken {
+				existing[fingerprint] = story
+				continue
+			}
+		}
+
+		prune = append(prune, story)
+	}
+
+	epics := NewEpicLabels(r.client, r.writer)
+
+	var report Reconciliation
+	position := r.params.Position
+	ids := map[string]int{}
+	created := map[int]ManifestStory{}
+	var createdIDs []int
+
+	for _, entry := range entries {
+		if err := r.ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		var epic *tracker.Label
+		if entry.Epic != "" {
+			label, err := epics.Label(entry.Epic)
+			if err != nil {
+				return nil, fmt.Errorf("finding epic: %s", err)
+			}
+
+			epic = &label
+		}
+
+		desired := sync.Desired(entry, epic)
+
+		if story, found := existing[sync.Fingerprint(entry)]; found {
+			if entry.ID != "" {
+				ids[entry.ID] = story.ID
+			}
+
+			update, fields := Drift(story, desired)
+			if len(fields) == 0 {
+				report.Unchanged++
+				continue
+			}
+
+			if _, err := r.writer.UpdateStory(update); err != nil {
+				return nil, fmt.Errorf("updating story: %s", err)
+			}
+
+			r.env.Logf("Story %d updated: %s\n", story.ID, strings.Join(fields, ", "))
+			report.Updated++
+			continue
+		}
+
+		desired, err = position.Place(desired, r.client)
+		if err != nil {
+			return nil, fmt.Errorf("positioning story: %s", err)
+		}
+
+		story, err := r.writer.CreateStory(desired)
+		if err != nil {
+			return nil, fmt.Errorf("creating story: %s", err)
+		}
+
+		position = position.Next(story)
+
+		r.env.Logf("Story created with ID: %d Name: %s\n", story.ID, story.Name)
+		report.Created++
+
+		if entry.ID != "" {
+			ids[entry.ID] = story.ID
+		}
+
+		created[story.ID] = entry
+		createdIDs = append(createdIDs, story.ID)
+	}
+
+	// blockers are only added to new stories; existing ones keep theirs
+	for _, id := range createdIDs {
+		blockers, err := created[id].Blockers(ids)
+		if err != nil {
+			return nil, fmt.Errorf("resolving blockers: %s", err)
+		}
+
+		for _, blocker := range blockers {
+			_, err := r.writer.CreateBlocker(id, tracker.Blocker{
+				Description: fmt.Sprintf("#%d", blocker),
+			})
+			if err != nil {
+				return nil, fmt.Errorf("creating blocker: %s", err)
+			}
+
+			r.env.Logf("Story %d blocked by %d\n", id, blocker)
+		}
+	}
+
+	sort.Slice(prune, func(i, j int) bool { return prune[i].ID < prune[j].ID })
+
+	for _, story := range prune {
+		if err := r.ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		// accepted stories are history, not drift
+		if story.State == tracker.StoryStateAccepted {
+			continue
+		}
+
+		if sync.Prune == PruneDelete {
+			if err := r.writer.DeleteStory(story.ID); err != nil {
+				return nil, fmt.Errorf("deleting story: %s", err)
+			}
+
+			r.env.Logf("Story %d deleted\n", story.ID)
+			report.Pruned++
+			continue
+		}
+
+		if resource.HasLabel(story, sync.OrphanLabelName()) {
+			continue
+		}
+
+		if _, err := r.writer.AddStoryLabel(story.ID, tracker.Label{Name: sync.OrphanLabelName()}); err != nil {
+			return nil, fmt.Errorf("labeling story: %s", err)
+		}
+
+		r.env.Logf("Story %d labeled %s\n", story.ID, sync.OrphanLabelName())
+		report.Pruned++
+	}
+
+	r.env.Logf("%s\n", report)
+
+	return report.Metadata(), nil
+}
+
+func (r run) importStories(imp Import) ([]resource.MetadataPair, error) {
+	stories, err := ReadExport(filepath.Join(r.sources, imp.File))
+	if err != nil {
+		return nil, fmt.Errorf("reading export: %s", err)
+	}
+
+	// the project is the record of what earlier imports did, as a put
+	// cannot keep files between builds
+	project, err := resource.AllStories(r.client, tracker.StoriesQuery{Fields: ImportFields})
+	if err != nil {
+		return nil, fmt.Errorf("fetching imported stories: %s", err)
+	}
+
+	existing := map[string]tracker.Story{}
+	for _, story := range project {
+		if marker, found := ParseMarker(story.Description); found {
+			existing[marker.Fingerprint] = story
+		}
+	}
+
+	settings, err := r.client.Project()
+	if err != nil {
+		return nil, fmt.Errorf("fetching project: %s", err)
+	}
+
+	mapping := ImportMapping{Stories: map[int]*ImportedStory{}}
+	current := map[int]tracker.Story{}
+	var imported, comments, blockers int
+
+	for _, story := range stories {
+		if err := r.ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		if found, ok := existing[ImportFingerprint(story)]; ok {
+			mapping.Stories[story.ID] = &ImportedStory{ID: found.ID}
+			current[story.ID] = found
+			continue
+		}
+
+		created, err := r.writer.CreateStory(ImportStory(story, settings))
+		if err != nil {
+			return nil, fmt.Errorf("creating story: %s", err)
+		}
+
+		mapping.Stories[story.ID] = &ImportedStory{ID: created.ID}
+		current[story.ID] = created
+
+		r.env.Logf("Story %d imported as %d\n", story.ID, created.ID)
+		imported++
+	}
+
+	// references can only be remapped once every story exists
+	for _, story := range stories {
+		if err := r.ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		target := current[story.ID]
+
+		description := WithMarker(mapping.Remap(story.Description), Marker{Fingerprint: ImportFingerprint(story)})
+		if description != target.Description {
+			_, err := r.writer.UpdateStory(tracker.Story{ID: target.ID, Description: description})
+			if err != nil {
+				return nil, fmt.Errorf("updating story: %s", err)
+			}
+		}
+
+		copied := map[string]int{}
+		for _, comment := range target.Comments {
+			copied[comment.Text]++
+		}
+
+		for _, comment := range story.Comments {
+			text := mapping.Remap(comment.Text)
+			if copied[text] > 0 {
+				copied[text]--
+				continue
+			}
+
+			_, err := r.writer.CreateComment(target.ID, tracker.Comment{Text: text})
+			if err != nil {
+				return nil, fmt.Errorf("commenting on story: %s", err)
+			}
+
+			comments++
+		}
+
+		copied = map[string]int{}
+		for _, blocker := range target.Blockers {
+			copied[blocker.Description]++
+		}
+
+		for _, blocker := range story.Blockers {
+			description := mapping.Remap(blocker.Description)
+			if copied[description] > 0 {
+				copied[description]--
+				continue
+			}
+
+			_, err := r.writer.CreateBlocker(target.ID, tracker.Blocker{
+				Description: description,
+				Resolved:    blocker.Resolved,
+			})
+			if err != nil {
+				return nil, fmt.Errorf("creating blocker: %s", err)
+			}
+
+			blockers++
+		}
+	}
+
+	if imp.Mapping != "" && !r.params.DryRun {
+		path := imp.Mapping
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(r.sources, path)
+		}
+
+		if err := mapping.Write(path); err != nil {
+			return nil, fmt.Errorf("writing import mapping: %s", err)
+		}
+	}
+
+	r.env.Logf("Import: %d stories, %d comments, %d blockers\n", imported, comments, blockers)
+
+	return []resource.MetadataPair{
+		{Name: "imported_stories", Value: fmt.Sprintf("%d", imported)},
+		{Name: "imported_comments", Value: fmt.Sprintf("%d", comments)},
+		{Name: "imported_blockers", Value: fmt.Sprintf("%d", blockers)},
+	}, nil
+}
+
+func (r run) mirrorStories(mirror Mirror) ([]resource.MetadataPair, error) {
+	if err := mirror.Validate(r.source); err != nil {
+		return nil, err
+	}
+	mirror.From = mirror.Origin(r.source)
+
+	origin, err := r.env.OriginClient(r.ctx, mirror.From)
+	if err != nil {
+		return nil, fmt.Errorf("mirrored project: %s", err)
+	}
+
+	// links back are written to the original project, or planned for it
+	var originWriter Writer = origin
+	if r.plan != nil {
+		projectID, _ := strconv.Atoi(mirror.From.ProjectID)
+		originWriter = r.plan.InProject(origin, projectID)
+	}
+
+	originals, err := resource.AllStories(origin, tracker.StoriesQuery{
+		Label:  mirror.Label,
+		Filter: mirror.Filter,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("fetching mirrored stories: %s", err)
+	}
+
+	stories, err := resource.AllStories(r.client, tracker.StoriesQuery{})
+	if err != nil {
+		return nil, fmt.Errorf("fetching stories: %s", err)
+	}
+
+	project, err := r.client.Project()
+	if err != nil {
+		return nil, fmt.Errorf("fetching project: %s", err)
+	}
+
+	mirrors := map[int]tracker.Story{}
+	for _, story := range stories {
+		if id, found := mirror.Mirrored(story); found {
+			mirrors[id] = story
+		}
+	}
+
+	var report MirrorReport
+
+	// one story Tracker refuses should not keep the others out of step
+	for _, original := range originals {
+		if err := r.ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		if err := r.mirrorStory(mirror, original, mirrors, project, originWriter, &report); err != nil {
+			r.env.Logf("Story %d not mirrored: %s\n", original.ID, err)
+			report.Failed = append(report.Failed, MirrorFailure{OriginalID: original.ID, Err: err})
+		}
+	}
+
+	r.env.Logf("%s\n", report)
+
+	if len(report.Failed) > 0 {
+		return nil, report.Error()
+	}
+
+	return report.Metadata(), nil
+}
+
+func (r run) mirrorStory(mirror Mirror, original tracker.Story, mirrors map[int]tracker.Story, project tracker.Project, originWriter Writer, report *MirrorReport) error {
+	desired := mirror.Desired(original, project)
+
+	if existing, found := mirrors[original.ID]; found {
+		update, fields := MirrorDrift(existing, desired)
+		if len(fields) == 0 {
+			report.Unchanged++
+			return nil
+		}
+
+		if _, err := r.writer.UpdateStory(update); err != nil {
+			return fmt.Errorf("updating story: %s", err)
+		}
+
+		r.env.Logf("Story %d updated: %s\n", existing.ID, strings.Join(fields, ", "))
+		report.Updated++
+		return nil
+	}
+
+	story, err := r.writer.CreateStory(desired)
+	if err != nil {
+		return fmt.Errorf("creating story: %s", err)
+	}
+
+	r.env.Logf("Story %d mirrored as %d\n", original.ID, story.ID)
+	report.Created++
+
+	if _, err := originWriter.CreateComment(original.ID, tracker.Comment{Text: MirrorLink(story)}); err != nil {
+		return fmt.Errorf("linking mirrored story: %s", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,260 @@
+package out_test
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/XenoPhex/go-tracker"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+
+	"github.com/cjcjameson/tracker-story-resource"
+	"github.com/cjcjameson/tracker-story-resource/out"
+	"github.com/cjcjameson/tracker-story-resource/trackerfake"
+)
+
+var _ = Describe("Run", func() {
+	var (
+		fake    *trackerfake.Server
+		env     resource.Env
+		log     *gbytes.Buffer
+		sources string
+		now     time.Time
+	)
+
+	BeforeEach(func() {
+		fake = trackerfake.New("abc", 1234)
+
+		client, err := resource.NewProjectClient(fake.Source())
+		Expect(err).NotTo(HaveOccurred())
+
+		log = gbytes.NewBuffer()
+		now = time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+		env = resource.Env{
+			Client: client,
+			Log:    log,
+			Now:    func() time.Time { return now },
+		}
+
+		sources, err = ioutil.TempDir("", "out-run")
+		Expect(err).NotTo(HaveOccurred())
+
+		err = ioutil.WriteFile(filepath.Join(sources, "junit.xml"), []byte(Fixture("junit.xml")), os.ModePerm)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		fake.Close()
+		os.RemoveAll(sources)
+	})
+
+	It("files findings with the injected client, clock and log", func() {
+		response, err := out.Run(context.Background(), out.OutRequest{
+			Params: out.Params{ContentPath: "junit.xml", Format: "junit"},
+		}, sources, env)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(response.Version.Time).To(Equal(now))
+		Expect(log).To(gbytes.Say("Story created with ID: \\d+ Name: deathstar.TractorBeamTest/testReleasesShips"))
+
+		stories := fake.Stories()
+		Expect(stories).To(HaveLen(2))
+
+		marker, found := out.ParseMarker(stories[0].Description)
+		Expect(found).To(BeTrue())
+		Expect(marker.Occurrences).To(Equal([]time.Time{now}))
+	})
+
+	It("closes the stories a grouped finding replaces", func() {
+		err := ioutil.WriteFile(filepath.Join(sources, "sarif.json"), []byte(Fixture("sarif.json")), os.ModePerm)
+		Expect(err).NotTo(HaveOccurred())
+
+		var singles []tracker.Story
+		for _, file := range []string{"beam/beam.go", "port/port.go"} {
+			singles = append(singles, fake.AddStory(tracker.Story{
+				Name:        "G104: " + file,
+				Type:        tracker.StoryTypeChore,
+				Description: out.WithMarker("", out.Marker{Fingerprint: "sarif:gosec:G104:" + file}),
+			}))
+		}
+
+		_, err = out.Run(context.Background(), out.OutRequest{
+			Params: out.Params{ContentPath: "sarif.json", Format: "sarif"},
+		}, sources, env)
+		Expect(err).NotTo(HaveOccurred())
+
+		var grouped tracker.Story
+		for _, story := range fake.Stories() {
+			if story.Name == "G104 (4 locations)" {
+				grouped = story
+			}
+		}
+		Expect(grouped.ID).NotTo(BeZero())
+
+		for _, single := range singles {
+			story, _ := fake.Story(single.ID)
+			Expect(story.State).To(BeEquivalentTo(tracker.StoryStateAccepted))
+			comments := fake.Comments(single.ID)
+			Expect(comments).To(HaveLen(1))
+			Expect(comments[0].Text).To(Equal(fmt.Sprintf("Replaced by #%d.", grouped.ID)))
+		}
+	})
+
+	It("brings a grouped finding's story up to date with its locations", func() {
+		err := ioutil.WriteFile(filepath.Join(sources, "sarif.json"), []byte(Fixture("sarif.json")), os.ModePerm)
+		Expect(err).NotTo(HaveOccurred())
+
+		report, err := out.ParseSARIF([]byte(Fixture("sarif.json")), out.SARIF{})
+		Expect(err).NotTo(HaveOccurred())
+
+		var finding out.Finding
+		for _, f := range report.Findings {
+			if f.Fingerprint == "sarif:gosec:G104" {
+				finding = f
+			}
+		}
+		Expect(finding.Tasks).To(HaveLen(4))
+
+		grouped := fake.AddStory(tracker.Story{
+			Name:        "G104 (5 locations)",
+			Type:        tracker.StoryTypeChore,
+			Description: out.WithMarker("* Locations: 5", out.Marker{Fingerprint: "sarif:gosec:G104"}),
+			Tasks: []tracker.Task{
+				{Description: finding.Tasks[0], Complete: true},
+				{Description: "gone/gone.go:1: fixed since"},
+			},
+		})
+
+		_, err = out.Run(context.Background(), out.OutRequest{
+			Params: out.Params{ContentPath: "sarif.json", Format: "sarif"},
+		}, sources, env)
+		Expect(err).NotTo(HaveOccurred())
+
+		story, _ := fake.Story(grouped.ID)
+		Expect(story.Name).To(Equal("G104 (4 locations)"))
+		Expect(story.Description).To(ContainSubstring("* Locations: 4"))
+
+		marker, found := out.ParseMarker(story.Description)
+		Expect(found).To(BeTrue())
+		Expect(marker.Fingerprint).To(Equal("sarif:gosec:G104"))
+
+		var descriptions []string
+		for _, task := range story.Tasks {
+			descriptions = append(descriptions, task.Description)
+		}
+		Expect(descriptions).To(Equal(finding.Tasks))
+		Expect(story.Tasks[0].Complete).To(BeTrue())
+		Expect(log).To(gbytes.Say("Story %d now has 4 locations", grouped.ID))
+	})
+
+	It("comments on stories whose checks pass again even without resolve", func() {
+		passing := fake.AddStory(tracker.Story{
+			Name:        "deathstar.TractorBeamTest/testHoldsShips",
+			Type:        tracker.StoryTypeBug,
+			State:       tracker.StoryStateStarted,
+			Description: out.WithMarker("", out.Marker{Fingerprint: "junit:deathstar.TractorBeamTest/testHoldsShips"}),
+		})
+
+		_, err := out.Run(context.Background(), out.OutRequest{
+			Params: out.Params{ContentPath: "junit.xml", Format: "junit"},
+		}, sources, env)
+		Expect(err).NotTo(HaveOccurred())
+
+		story, _ := fake.Story(passing.ID)
+		Expect(story.State).To(BeEquivalentTo(tracker.StoryStateStarted))
+
+		marker, _ := out.ParseMarker(story.Description)
+		Expect(marker.PassedAt).NotTo(BeNil())
+
+		comments := fake.Comments(passing.ID)
+		Expect(comments).To(HaveLen(1))
+		Expect(comments[0].Text).To(Equal("Passed again."))
+		Expect(log).To(gbytes.Say("Story %d passed\n", passing.ID))
+	})
+
+	It("comments with the current time on alerts resolved without an end time", func() {
+		err := ioutil.WriteFile(filepath.Join(sources, "alerts.json"), []byte(`{"alerts": [{"status": "resolved", "fingerprint": "9c8d7e", "labels": {"alertname": "ExhaustPortOpen"}}]}`), os.ModePerm)
+		Expect(err).NotTo(HaveOccurred())
+
+		firing := fake.AddStory(tracker.Story{
+			Name:        "ExhaustPortOpen",
+			Type:        tracker.StoryTypeBug,
+			State:       tracker.StoryStateStarted,
+			Description: out.WithMarker("", out.Marker{Fingerprint: "alert:9c8d7e"}),
+		})
+
+		_, err = out.Run(context.Background(), out.OutRequest{
+			Params: out.Params{ContentPath: "alerts.json", Format: "alertmanager"},
+		}, sources, env)
+		Expect(err).NotTo(HaveOccurred())
+
+		comments := fake.Comments(firing.ID)
+		Expect(comments).To(HaveLen(1))
+		Expect(comments[0].Text).To(Equal("Resolved at 2026-10-19T12:00:00Z."))
+	})
+
+	Describe("placing stories into the current iteration", func() {
+		BeforeEach(func() {
+			fake.AddStory(tracker.Story{Name: "Already planned", State: tracker.StoryStateUnstarted})
+
+			err := ioutil.WriteFile(filepath.Join(sources, "stories.yml"), []byte("stories: [{name: first}, {name: second}]"), os.ModePerm)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		put := func() error {
+			_, err := out.Run(context.Background(), out.OutRequest{
+				Params: out.Params{
+					ManifestPath: "stories.yml",
+					Position:     out.Position{IntoCurrentIteration: true},
+				},
+			}, sources, env)
+			return err
+		}
+
+		names := func() []string {
+			var names []string
+			for _, story := range fake.Stories() {
+				names = append(names, story.Name)
+			}
+			return names
+		}
+
+		It("puts them in order ahead of the first unstarted story when Tracker plans iterations", func() {
+			Expect(put()).To(Succeed())
+			Expect(names()).To(Equal([]string{"first", "second", "Already planned"}))
+			Expect(fake.Stories()[0].State).To(BeEquivalentTo(tracker.StoryStateUnstarted))
+		})
+
+		It("plans them when the project's iterations are planned by hand", func() {
+			fake.AutomaticPlanning = false
+
+			Expect(put()).To(Succeed())
+			for _, story := range fake.Stories() {
+				if story.Name != "Already planned" {
+					Expect(story.State).To(BeEquivalentTo(tracker.StoryStatePlanned))
+				}
+			}
+		})
+	})
+
+	It("returns errors instead of exiting", func() {
+		_, err := out.Run(context.Background(), out.OutRequest{}, sources, env)
+		Expect(err).To(MatchError("no content file specified"))
+	})
+
+	It("stops when the context is cancelled", func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		_, err := out.Run(ctx, out.OutRequest{
+			Params: out.Params{ContentPath: "junit.xml", Format: "junit"},
+		}, sources, env)
+		Expect(err).To(Equal(context.Canceled))
+		Expect(fake.Stories()).To(BeEmpty())
+		Expect(fake.Requests()).To(HaveLen(1))
+	})
+})
@@ -0,0 +1,306 @@
+package out
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/XenoPhex/go-tracker"
+)
+
+const defaultGroupThreshold = 3
+
+var sarifLevels = map[string]int{
+	"none":    0,
+	"note":    1,
+	"warning": 2,
+	"error":   3,
+}
+
+type SARIF struct {
+	MinSeverity    string `json:"min_severity"`
+	GroupThreshold int    `json:"group_threshold"`
+}
+
+type sarifLog struct {
+	Runs []struct {
+		Tool struct {
+			Driver struct {
+				Name  string      `json:"name"`
+				Rules []sarifRule `json:"rules"`
+			} `json:"driver"`
+		} `json:"tool"`
+		Results []struct {
+			RuleID    string `json:"ruleId"`
+			RuleIndex *int   `json:"ruleIndex"`
+			Level     string `json:"level"`
+			Message   struct {
+				Text string `json:"text"`
+			} `json:"message"`
+			PartialFingerprints map[string]string `json:"partialFingerprints"`
+			Locations           []struct {
+				PhysicalLocation struct {
+					ArtifactLocation struct {
+						URI string `json:"uri"`
+					} `json:"artifactLocation"`
+					Region struct {
+						StartLine int `json:"startLine"`
+					} `json:"region"`
+				} `json:"physicalLocation"`
+			} `json:"locations"`
+		} `json:"results"`
+	} `json:"runs"`
+}
+
+type sarifRule struct {
+	ID               string `json:"id"`
+	ShortDescription struct {
+		Text string `json:"text"`
+	} `json:"shortDescription"`
+	HelpURI              string `json:"helpUri"`
+	DefaultConfiguration struct {
+		Level string `json:"level"`
+	} `json:"defaultConfiguration"`
+}
+
+type sarifLocation struct {
+	fingerprint string
+	uri         string
+	location    string
+	message     string
+}
+
+type sarifRuleResults struct {
+	tool      string
+	rule      sarifRule
+	level     string
+	locations []sarifLocation
+}
+
+// ParseSARIF files a story per rule and file for the results of a SARIF 2.1
+// log at or above the minimum severity, or per rule and partial fingerprint
+// when the tool gives them, so that edits elsewhere in a file do not file the
+// finding again. Errors are bugs and anything else is a chore. A rule with
+// more locations than the group threshold gets a single story with a task per
+// location instead, which replaces the stories filed for them on their own.
+func ParseSARIF(contents []byte, options SARIF) (Report, error) {
+	var log sarifLog
+	if err := json.Unmarshal(contents, &log); err != nil {
+		return Report{}, fmt.Errorf("invalid sarif log: %s", err)
+	}
+
+	minSeverity := options.MinSeverity
+	if minSeverity == "" {
+		minSeverity = "warning"
+	}
+
+	minLevel, found := sarifLevels[minSeverity]
+	if !found {
+		return Report{}, fmt.Errorf("unknown sarif severity: %s", minSeverity)
+	}
+
+	threshold := options.GroupThreshold
+	if threshold == 0 {
+		threshold = defaultGroupThreshold
+	}
+
+	byRule := map[string]*sarifRuleResults{}
+	var order []string
+
+	for _, run := range log.Runs {
+		tool := run.Tool.Driver.Name
+
+		rules := map[string]sarifRule{}
+		for _, rule := range run.Tool.Driver.Rules {
+			rules[rule.ID] = rule
+		}
+
+		for _, result := range run.Results {
+			rule, found := rules[result.RuleID]
+			if !found && result.RuleIndex != nil && *result.RuleIndex < len(run.Tool.Driver.Rules) {
+				rule = run.Tool.Driver.Rules[*result.RuleIndex]
+			}
+			if rule.ID == "" {
+				rule.ID = result.RuleID
+			}
+
+			level := result.Level
+			if level == "" {
+				level = rule.DefaultConfiguration.Level
+			}
+			if level == "" {
+				level = "warning"
+			}
+
+			if sarifLevels[level] < minLevel {
+				continue
+			}
+
+			key := tool + "\x00" + rule.ID
+			results, found := byRule[key]
+			if !found {
+				results = &sarifRuleResults{tool: tool, rule: rule, level: level}
+				byRule[key] = results
+				order = append(order, key)
+			}
+
+			if sarifLevels[level] > sarifLevels[results.level] {
+				results.level = level
+			}
+
+			partial := partialFingerprint(result.PartialFingerprints)
+
+			for _, location := range result.Locations {
+				physical := location.PhysicalLocation
+
+				fingerprint := results.fingerprint() + ":" + physical.ArtifactLocation.URI
+				if partial != "" {
+					fingerprint = results.fingerprint() + ":" + partial
+				}
+
+				results.locations = append(results.locations, sarifLocation{
+					fingerprint: fingerprint,
+					uri:         physical.ArtifactLocation.URI,
+					location:    fmt.Sprintf("%s:%d", physical.ArtifactLocation.URI, physical.Region.StartLine),
+					message:     result.Message.Text,
+				})
+			}
+		}
+	}
+
+	var report Report
+	for _, key := range order {
+		results := byRule[key]
+
+		if len(results.locations) > threshold {
+			report.Findings = append(report.Findings, results.grouped())
+			continue
+		}
+
+		// locations sharing a fingerprint, like two in the same file, share
+		// a story
+		byFingerprint := map[string][]sarifLocation{}
+		var fingerprints []string
+		for _, location := range results.locations {
+			if _, found := byFingerprint[location.fingerprint]; !found {
+				fingerprints = append(fingerprints, location.fingerprint)
+			}
+
+			byFingerprint[location.fingerprint] = append(byFingerprint[location.fingerprint], location)
+		}
+
+		for _, fingerprint := range fingerprints {
+			report.Findings = append(report.Findings, results.single(byFingerprint[fingerprint]))
+		}
+	}
+
+	return report, nil
+}
+
+// partialFingerprint joins the partial fingerprints of a result, which tools
+// compute to survive changes to the lines around it.
+func partialFingerprint(fingerprints map[string]string) string {
+	var keys []string
+	for key := range fingerprints {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var values []string
+	for _, key := range keys {
+		values = append(values, fingerprints[key])
+	}
+
+	return strings.Join(values, ":")
+}
+
+func (r sarifRuleResults) fingerprint() string {
+	return "sarif:" + r.tool + ":" + r.rule.ID
+}
+
+func (r sarifRuleResults) storyType() tracker.StoryType {
+	if r.level == "error" {
+		return tracker.StoryTypeBug
+	}
+
+	return tracker.StoryTypeChore
+}
+
+func (r sarifRuleResults) labels() []string {
+	return []string{"static-analysis", "severity:" + r.level}
+}
+
+func (r sarifRuleResults) ruleDescription() []string {
+	lines := []string{}
+	if r.rule.ShortDescription.Text != "" {
+		lines = append(lines, r.rule.ShortDescription.Text, "")
+	}
+
+	lines = append(lines, fmt.Sprintf("* Tool: %s", r.tool), fmt.Sprintf("* Rule: %s", r.rule.ID))
+	if r.rule.HelpURI != "" {
+		lines = append(lines, "* Help: "+r.rule.HelpURI)
+	}
+
+	return lines
+}
+
+func (r sarifRuleResults) single(locations []sarifLocation) Finding {
+	lines := r.ruleDescription()
+
+	var messages []string
+	seen := map[string]bool{}
+	for _, location := range locations {
+		lines = append(lines, "* Location: "+location.location)
+
+		if location.message != "" && !seen[location.message] {
+			seen[location.message] = true
+			messages = append(messages, location.message)
+		}
+	}
+
+	if len(messages) > 0 {
+		lines = append(lines, "", strings.Join(messages, "\n"))
+	}
+
+	return Finding{
+		Fingerprint: locations[0].fingerprint,
+		Name:        fmt.Sprintf("%s: %s", r.rule.ID, locations[0].uri),
+		Description: strings.Join(lines, "\n"),
+		Type:        r.storyType(),
+		Labels:      r.labels(),
+		Standing:    true,
+		Replaces:    []string{r.fingerprint()},
+	}
+}
+
+func (r sarifRuleResults) grouped() Finding {
+	lines := append(r.ruleDescription(), fmt.Sprintf("* Locations: %d", len(r.locations)))
+
+	var tasks []string
+	var replaces []string
+	seen := map[string]bool{}
+	for _, location := range r.locations {
+		task := location.location
+		if location.message != "" {
+			task += ": " + location.message
+		}
+		tasks = append(tasks, task)
+
+		if !seen[location.fingerprint] {
+			seen[location.fingerprint] = true
+			replaces = append(replaces, location.fingerprint)
+		}
+	}
+
+	return Finding{
+		Fingerprint: r.fingerprint(),
+		Name:        fmt.Sprintf("%s (%d locations)", r.rule.ID, len(r.locations)),
+		Description: strings.Join(lines, "\n"),
+		Type:        r.storyType(),
+		Labels:      r.labels(),
+		Tasks:       tasks,
+		Standing:    true,
+		Replaces:    replaces,
+	}
+}
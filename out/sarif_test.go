@@ -0,0 +1,89 @@
+package out_test
+
+import (
+	"github.com/XenoPhex/go-tracker"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/cjcjameson/tracker-story-resource/out"
+)
+
+var _ = Describe("Parsing SARIF logs", func() {
+	var (
+		options out.SARIF
+		report  out.Report
+	)
+
+	BeforeEach(func() {
+		options = out.SARIF{}
+	})
+
+	JustBeforeEach(func() {
+		var err error
+		report, err = out.ParseSARIF([]byte(Fixture("sarif.json")), options)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("files a bug for each rule and file with errors", func() {
+		finding := report.Findings[0]
+		Expect(finding.Name).To(Equal("G101: cmd/deathstar/main.go"))
+		Expect(finding.Fingerprint).To(Equal("sarif:gosec:G101:cmd/deathstar/main.go"))
+		Expect(finding.Type).To(BeEquivalentTo(tracker.StoryTypeBug))
+		Expect(finding.Labels).To(Equal([]string{"static-analysis", "severity:error"}))
+		Expect(finding.Description).To(Equal(`Look for hard coded credentials
+
+* Tool: gosec
+* Rule: G101
+* Help: https://securego.io/docs/rules/g101.html
+* Location: cmd/deathstar/main.go:12
+
+Potential hardcoded credentials`))
+	})
+
+	It("groups rules with many locations into a chore with a task per location", func() {
+		finding := report.Findings[1]
+		Expect(finding.Name).To(Equal("G104 (4 locations)"))
+		Expect(finding.Fingerprint).To(Equal("sarif:gosec:G104"))
+		Expect(finding.Type).To(BeEquivalentTo(tracker.StoryTypeChore))
+		Expect(finding.Tasks).To(Equal([]string{
+			"beam/beam.go:10: Errors unhandled.",
+			"beam/beam.go:22: Errors unhandled.",
+			"port/port.go:5: Errors unhandled.",
+			"port/port.go:9: Errors unhandled.",
+		}))
+	})
+
+	It("replaces the stories filed for each file once a rule is grouped", func() {
+		Expect(report.Findings[1].Replaces).To(Equal([]string{
+			"sarif:gosec:G104:beam/beam.go",
+			"sarif:gosec:G104:port/port.go",
+		}))
+	})
+
+	It("leaves out results below warning by default", func() {
+		Expect(report.Findings).To(HaveLen(2))
+	})
+
+	Context("with a lower minimum severity and a higher group threshold", func() {
+		BeforeEach(func() {
+			options = out.SARIF{MinSeverity: "note", GroupThreshold: 10}
+		})
+
+		It("files every file on its own, with its locations", func() {
+			Expect(report.Findings).To(HaveLen(4))
+			Expect(report.Findings[1].Name).To(Equal("G104: beam/beam.go"))
+			Expect(report.Findings[1].Description).To(ContainSubstring("* Location: beam/beam.go:10\n* Location: beam/beam.go:22\n\nErrors unhandled."))
+			Expect(report.Findings[1].Replaces).To(Equal([]string{"sarif:gosec:G104"}))
+		})
+
+		It("fingerprints on the tool's partial fingerprints when it gives them", func() {
+			Expect(report.Findings[3].Name).To(Equal("G307: port/port.go"))
+			Expect(report.Findings[3].Fingerprint).To(Equal("sarif:gosec:G307:5c1e3f0d2a9b7c44:1"))
+		})
+	})
+
+	It("rejects unknown severities", func() {
+		_, err := out.ParseSARIF([]byte(Fixture("sarif.json")), out.SARIF{MinSeverity: "catastrophic"})
+		Expect(err).To(MatchError("unknown sarif severity: catastrophic"))
+	})
+})
@@ -35,10 +35,12 @@ pushd $DIR/git
 	git commit -m "add file [Finishes #123456]"
 	git commit -m "add file [#223456 Finishes]" --allow-empty
 	git commit -m "add file [Finished #323456]" --allow-empty
+	git tag v1.0.0
 	git commit -m "add file [Finish #423456, #789456]" --allow-empty
 	git commit -m "add file [Completes #523456]" --allow-empty
 	git commit -m "add file [Completed #623456]" --allow-empty
 	git commit -m "add file [Complete #723456]" --allow-empty
+	git tag v1.1.0
 popd
 
 # git2: git harder directory
@@ -62,6 +64,20 @@ pushd $DIR/middle/git2
 	git commit -m "fix previously rejected story badly so it gets rejected again [Complete #666666]" --allow-empty
 popd
 
+# todos: git directory with code comment markers
+mkdir -p $DIR/todos
+pushd $DIR/todos
+	git init
+	git remote add origin git@github.com:deathstar/reactor.git
+
+	git config user.email "concourse@example.com"
+	git config user.name "Concourse Tracker Resource"
+
+	printf 'package reactor\n\n// TODO(galen): cover the exhaust port\nfunc Vent() {\n\t/* FIXME shielding drops under load */\n}\n' > reactor.go
+	git add reactor.go
+	git commit -m "add reactor"
+popd
+
 if [ ! -z ${ACTUAL_STORY_ID} ]; then
 	echo "ACTUAL_STORY_ID: ${ACTUAL_STORY_ID}"
 	# git3: git with a vengence directory
@@ -0,0 +1,151 @@
+package out
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/XenoPhex/go-tracker"
+	"github.com/cjcjameson/tracker-story-resource"
+)
+
+const (
+	PruneOrphan = "orphan"
+	PruneDelete = "delete"
+)
+
+// Sync makes a manifest the desired state of every story with the owner
+// label: missing stories are created, drifted ones updated and the ones no
+// longer in the manifest pruned, by deleting them or labeling them orphaned.
+type Sync struct {
+	Manifest    string `json:"manifest"`
+	Owner       string `json:"owner"`
+	Prune       string `json:"prune"`
+	OrphanLabel string `json:"orphan_label"`
+
+	// AllowEmpty lets a manifest without stories prune every owned story.
+	// Without it such a manifest is taken for a truncated or missing one.
+	AllowEmpty bool `json:"allow_empty"`
+}
+
+func (s Sync) Validate() error {
+	if s.Manifest == "" {
+		return errors.New("sync needs a manifest")
+	}
+
+	if s.Owner == "" {
+		return errors.New("sync needs an owner label")
+	}
+
+	switch s.Prune {
+	case "", PruneOrphan, PruneDelete:
+		return nil
+	}
+
+	return fmt.Errorf("unknown prune mode: %s", s.Prune)
+}
+
+func (s Sync) OrphanLabelName() string {
+	if s.OrphanLabel == "" {
+		return "orphaned"
+	}
+
+	return s.OrphanLabel
+}
+
+// Fingerprint identifies a manifest story across syncs, by its id or else its
+// name.
+func (s Sync) Fingerprint(entry ManifestStory) string {
+	key := entry.ID
+	if key == "" {
+		key = entry.Name
+	}
+
+	return "sync:" + s.Owner + ":" + key
+}
+
+// Desired is the story as the manifest wants it, with its marker, owner label
+// and epic label.
+func (s Sync) Desired(entry ManifestStory, epic *tracker.Label) tracker.Story {
+	story := entry.Story()
+	story.Description = WithMarker(entry.Description, Marker{Fingerprint: s.Fingerprint(entry)})
+
+	if epic != nil {
+		story.Labels = append(story.Labels, *epic)
+	}
+
+	if !resource.HasLabel(story, s.Owner) {
+		story.Labels = append(story.Labels, tracker.Label{Name: s.Owner})
+	}
+
+	return story
+}
+
+// Drift is the update that would bring a story to the desired one, and the
+// names of the fields it changes.
+func Drift(existing tracker.Story, desired tracker.Story) (tracker.Story, []string) {
+	update := tracker.Story{ID: existing.ID}
+	var fields []string
+
+	if existing.Name != desired.Name {
+		update.Name = desired.Name
+		fields = append(fields, "name")
+	}
+
+	if existing.Description != desired.Description {
+		update.Description = desired.Description
+		fields = append(fields, "description")
+	}
+
+	if existing.Type != desired.Type {
+		update.Type = desired.Type
+		fields = append(fields, "story_type")
+	}
+
+	if !sameLabels(existing.Labels, desired.Labels) {
+		update.Labels = desired.Labels
+		fields = append(fields, "labels")
+	}
+
+	return update, fields
+}
+
+// Reconciliation counts what a sync did to the owner's stories.
+type Reconciliation struct {
+	Created   int
+	Updated   int
+	Unchanged int
+	Pruned    int
+}
+
+func (r Reconciliation) String() string {
+	return fmt.Sprintf("Sync: %d created, %d updated, %d unchanged, %d pruned", r.Created, r.Updated, r.Unchanged, r.Pruned)
+}
+
+func (r Reconciliation) Metadata() []resource.MetadataPair {
+	return []resource.MetadataPair{
+		{Name: "created", Value: fmt.Sprintf("%d", r.Created)},
+		{Name: "updated", Value: fmt.Sprintf("%d", r.Updated)},
+		{Name: "unchanged", Value: fmt.Sprintf("%d", r.Unchanged)},
+		{Name: "pruned", Value: fmt.Sprintf("%d", r.Pruned)},
+	}
+}
+
+func sameLabels(a, b []tracker.Label) bool {
+	return strings.Join(labelNames(a), "\x00") == strings.Join(labelNames(b), "\x00")
+}
+
+func labelNames(labels []tracker.Label) []string {
+	seen := map[string]bool{}
+	var names []string
+	for _, label := range labels {
+		if !seen[label.Name] {
+			seen[label.Name] = true
+			names = append(names, label.Name)
+		}
+	}
+
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,224 @@
+package out_test
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/XenoPhex/go-tracker"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+
+	"github.com/cjcjameson/tracker-story-resource"
+	"github.com/cjcjameson/tracker-story-resource/out"
+	"github.com/cjcjameson/tracker-story-resource/trackerfake"
+)
+
+var _ = Describe("Sync", func() {
+	var (
+		fake    *trackerfake.Server
+		env     resource.Env
+		log     *gbytes.Buffer
+		sources string
+		sync    *out.Sync
+	)
+
+	BeforeEach(func() {
+		fake = trackerfake.New("abc", 1234)
+
+		log = gbytes.NewBuffer()
+		env = fake.Env(log, time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC))
+
+		var err error
+		sources, err = ioutil.TempDir("", "out-sync")
+		Expect(err).NotTo(HaveOccurred())
+
+		sync = &out.Sync{Manifest: "chores.yml", Owner: "managed-by:ops"}
+	})
+
+	AfterEach(func() {
+		fake.Close()
+		os.RemoveAll(sources)
+	})
+
+	writeManifest := func(contents string) {
+		err := ioutil.WriteFile(filepath.Join(sources, "chores.yml"), []byte(contents), os.ModePerm)
+		Expect(err).NotTo(HaveOccurred())
+	}
+
+	syncStories := func() out.OutResponse {
+		response, err := out.Run(context.Background(), out.OutRequest{
+			Params: out.Params{Sync: sync},
+		}, sources, env)
+		Expect(err).NotTo(HaveOccurred())
+		return response
+	}
+
+	storyNamed := func(name string) tracker.Story {
+		for _, story := range fake.Stories() {
+			if story.Name == name {
+				return story
+			}
+		}
+
+		Fail("no story named " + name)
+		return tracker.Story{}
+	}
+
+	metadata := func(created, updated, unchanged, pruned string) []resource.MetadataPair {
+		return []resource.MetadataPair{
+			{Name: "created", Value: created},
+			{Name: "updated", Value: updated},
+			{Name: "unchanged", Value: unchanged},
+			{Name: "pruned", Value: pruned},
+		}
+	}
+
+	BeforeEach(func() {
+		writeManifest(`
+stories:
+- id: certs
+  name: Rotate certificates
+  labels: [security]
+- name: Patch the bastion
+  blocked_by: [certs]
+`)
+	})
+
+	It("creates the stories missing from the project", func() {
+		response := syncStories()
+		Expect(response.Metadata).To(Equal(metadata("2", "0", "0", "0")))
+		Expect(log).To(gbytes.Say(`Story created with ID: \d+ Name: Rotate certificates`))
+		Expect(log).To(gbytes.Say(`Sync: 2 created, 0 updated, 0 unchanged, 0 pruned`))
+
+		stories := fake.Stories()
+		Expect(stories).To(HaveLen(2))
+		for _, story := range stories {
+			Expect(resource.HasLabel(story, "managed-by:ops")).To(BeTrue())
+		}
+		Expect(resource.HasLabel(storyNamed("Rotate certificates"), "security")).To(BeTrue())
+		Expect(fake.Blockers(storyNamed("Patch the bastion").ID)).To(HaveLen(1))
+	})
+
+	Context("when the project already has the owner's stories", func() {
+		var handmade, accepted tracker.Story
+
+		BeforeEach(func() {
+			syncStories()
+
+			handmade = fake.AddStory(tracker.Story{
+				Name:   "Labeled by hand",
+				Labels: []tracker.Label{{Name: "managed-by:ops"}},
+			})
+			accepted = fake.AddStory(tracker.Story{
+				Name:   "Done long ago",
+				State:  tracker.StoryStateAccepted,
+				Labels: []tracker.Label{{Name: "managed-by:ops"}},
+			})
+			fake.AddStory(tracker.Story{Name: "Someone else's"})
+
+			log = gbytes.NewBuffer()
+			env.Log = log
+		})
+
+		It("leaves stories that match the manifest alone and adopts ones labeled by hand", func() {
+			writeManifest(`
+stories:
+- id: certs
+  name: Rotate certificates
+  labels: [security]
+- name: Patch the bastion
+  blocked_by: [certs]
+- name: Labeled by hand
+`)
+			sync.Prune = out.PruneDelete
+			requests := len(fake.Requests())
+
+			response := syncStories()
+			Expect(response.Metadata).To(Equal(metadata("0", "1", "2", "0")))
+			Expect(log).To(gbytes.Say(`Story %d updated: description`, handmade.ID))
+			Expect(fake.Requests()[requests:]).To(HaveLen(2))
+		})
+
+		It("updates drifted stories and labels the ones gone from the manifest orphaned", func() {
+			writeManifest(`
+stories:
+- id: certs
+  name: Rotate the TLS certificates
+  labels: [security, quarterly]
+`)
+
+			response := syncStories()
+			Expect(response.Metadata).To(Equal(metadata("0", "1", "0", "2")))
+			Expect(log).To(gbytes.Say(`Story \d+ updated: name, labels`))
+
+			Expect(resource.HasLabel(storyNamed("Rotate the TLS certificates"), "quarterly")).To(BeTrue())
+			Expect(resource.HasLabel(storyNamed("Patch the bastion"), "orphaned")).To(BeTrue())
+
+			story, _ := fake.Story(handmade.ID)
+			Expect(resource.HasLabel(story, "orphaned")).To(BeTrue())
+
+			story, _ = fake.Story(accepted.ID)
+			Expect(resource.HasLabel(story, "orphaned")).To(BeFalse())
+
+			Expect(syncStories().Metadata).To(Equal(metadata("0", "0", "1", "0")))
+		})
+
+		It("deletes the stories gone from the manifest when asked", func() {
+			writeManifest(`stories: [{id: certs, name: Rotate certificates, labels: [security]}]`)
+			sync.Prune = out.PruneDelete
+
+			response := syncStories()
+			Expect(response.Metadata).To(Equal(metadata("0", "0", "1", "2")))
+
+			Expect(fake.Stories()).To(HaveLen(3))
+			_, found := fake.Story(handmade.ID)
+			Expect(found).To(BeFalse())
+		})
+	})
+
+	It("rejects manifests with the same story twice", func() {
+		writeManifest(`stories: [{name: Rotate certificates}, {name: Rotate certificates}]`)
+
+		_, err := out.Run(context.Background(), out.OutRequest{
+			Params: out.Params{Sync: sync},
+		}, sources, env)
+		Expect(err).To(MatchError("duplicate story in manifest: sync:managed-by:ops:Rotate certificates"))
+		Expect(fake.Stories()).To(BeEmpty())
+	})
+
+	Context("when the manifest has no stories", func() {
+		BeforeEach(func() {
+			syncStories()
+			writeManifest(`stories: []`)
+			sync.Prune = out.PruneDelete
+		})
+
+		It("refuses to prune every owned story", func() {
+			_, err := out.Run(context.Background(), out.OutRequest{
+				Params: out.Params{Sync: sync},
+			}, sources, env)
+			Expect(err).To(MatchError("refusing to prune every owned story: the manifest has no stories, set allow_empty if it means to"))
+			Expect(fake.Stories()).To(HaveLen(2))
+		})
+
+		It("prunes them all when allowed to", func() {
+			sync.AllowEmpty = true
+
+			Expect(syncStories().Metadata).To(Equal(metadata("0", "0", "0", "2")))
+			Expect(fake.Stories()).To(BeEmpty())
+		})
+	})
+
+	It("rejects unknown prune modes", func() {
+		sync.Prune = "archive"
+
+		_, err := out.Run(context.Background(), out.OutRequest{
+			Params: out.Params{Sync: sync},
+		}, sources, env)
+		Expect(err).To(MatchError("unknown prune mode: archive"))
+	})
+})
@@ -0,0 +1,140 @@
+package out
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/XenoPhex/go-tracker"
+	"github.com/cjcjameson/tracker-story-resource"
+)
+
+var defaultTodoPatterns = []string{`TODO\([^)]*\)`, `FIXME`}
+
+// Todos files a chore for every TODO or FIXME comment in a repository.
+// Patterns are extended regular expressions; URL overrides the web address
+// of the repository used for permalinks, which is otherwise derived from its
+// origin remote.
+type Todos struct {
+	Repo     string   `json:"repo"`
+	Patterns []string `json:"patterns"`
+	URL      string   `json:"url"`
+}
+
+func (t Todos) patterns() []string {
+	if len(t.Patterns) == 0 {
+		return defaultTodoPatterns
+	}
+
+	return t.Patterns
+}
+
+// FingerprintPrefix is shared by the fingerprints of every chore filed for
+// the repository, so that chores for markers which have since disappeared
+// can be found. It names the repository by its whole path, as repositories
+// like src/api and vendor/api would otherwise close each other's chores.
+func (t Todos) FingerprintPrefix() string {
+	return "todo:" + filepath.ToSlash(filepath.Clean(t.Repo)) + ":"
+}
+
+// Findings scans the repository, checked out at dir, for markers.
+func (t Todos) Findings(dir string) ([]Finding, error) {
+	var expressions []*regexp.Regexp
+	for _, pattern := range t.patterns() {
+		expression, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern %s: %s", pattern, err)
+		}
+
+		expressions = append(expressions, expression)
+	}
+
+	matches, err := resource.Grep(dir, t.patterns())
+	if err != nil {
+		return nil, err
+	}
+
+	if len(matches) == 0 {
+		return nil, nil
+	}
+
+	head, err := resource.Head(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	url := strings.TrimSuffix(t.URL, "/")
+	if url == "" {
+		url = resource.WebURL(dir)
+	}
+
+	seen := map[string]int{}
+
+	var findings []Finding
+	for _, match := range matches {
+		text := markerText(match.Text, expressions)
+		if text == "" {
+			continue
+		}
+
+		author, err := resource.BlameAuthor(dir, match.File, match.Line)
+		if err != nil {
+			return nil, err
+		}
+
+		// the line number changes as the code around a marker does, so the
+		// fingerprint only counts repeats of the same text within a file
+		fingerprint := t.FingerprintPrefix() + match.File + ":" + text
+		seen[fingerprint]++
+		if seen[fingerprint] > 1 {
+			fingerprint += fmt.Sprintf(":%d", seen[fingerprint])
+		}
+
+		location := fmt.Sprintf("%s:%d", match.File, match.Line)
+
+		description := fmt.Sprintf("`%s`", location)
+		if author != "" {
+			description += " by " + author
+		}
+
+		if url != "" {
+			description += fmt.Sprintf("\n\n%s/blob/%s/%s#L%d", url, head, match.File, match.Line)
+		}
+
+		description += "\n\n```\n" + strings.TrimSpace(match.Text) + "\n```"
+
+		findings = append(findings, Finding{
+			Fingerprint: fingerprint,
+			Name:        text,
+			Description: description,
+			Type:        tracker.StoryTypeChore,
+			Labels:      []string{"todo"},
+			Standing:    true,
+		})
+	}
+
+	return findings, nil
+}
+
+// markerText is the part of a line from the first marker onwards, without
+// the end of a block comment.
+func markerText(line string, expressions []*regexp.Regexp) string {
+	start := -1
+	for _, expression := range expressions {
+		if location := expression.FindStringIndex(line); location != nil && (start == -1 || location[0] < start) {
+			start = location[0]
+		}
+	}
+
+	if start == -1 {
+		return ""
+	}
+
+	text := strings.TrimSpace(line[start:])
+	for _, end := range []string{"*/", "-->"} {
+		text = strings.TrimSpace(strings.TrimSuffix(text, end))
+	}
+
+	return text
+}
@@ -0,0 +1,202 @@
+package out
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+const unknownSeverity = "unknown"
+
+type vulnerability struct {
+	ID               string
+	Package          string
+	InstalledVersion string
+	FixedVersion     string
+	Severity         string
+	Summary          string
+	Details          string
+	Advisory         string
+}
+
+// vulnerabilityReport files one bug per vulnerability and package, however
+// many times a scanner reports the pair.
+func vulnerabilityReport(vulnerabilities []vulnerability) Report {
+	seen := map[string]bool{}
+
+	var report Report
+	for _, vuln := range vulnerabilities {
+		fingerprint := "vuln:" + vuln.ID + ":" + vuln.Package
+		if seen[fingerprint] {
+			continue
+		}
+		seen[fingerprint] = true
+
+		severity := strings.ToLower(vuln.Severity)
+		if severity == "" {
+			severity = unknownSeverity
+		}
+
+		report.Findings = append(report.Findings, Finding{
+			Fingerprint: fingerprint,
+			Name:        fmt.Sprintf("%s in %s", vuln.ID, vuln.Package),
+			Description: vulnerabilityDescription(vuln, severity),
+			Labels:      []string{"vulnerability", "severity:" + severity},
+			Standing:    true,
+		})
+	}
+
+	return report
+}
+
+func vulnerabilityDescription(vuln vulnerability, severity string) string {
+	lines := []string{}
+	if vuln.Summary != "" {
+		lines = append(lines, vuln.Summary, "")
+	}
+
+	affected := vuln.Package
+	if vuln.InstalledVersion != "" {
+		affected += "@" + vuln.InstalledVersion
+	}
+
+	fixed := vuln.FixedVersion
+	if fixed == "" {
+		fixed = "not fixed yet"
+	}
+
+	lines = append(lines,
+		"* Affected: "+affected,
+		"* Fixed in: "+fixed,
+		"* Severity: "+severity,
+	)
+
+	if vuln.Advisory != "" {
+		lines = append(lines, "* Advisory: "+vuln.Advisory)
+	}
+
+	if vuln.Details != "" {
+		lines = append(lines, "", trimLines(vuln.Details, stackLines))
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+type govulncheckMessage struct {
+	OSV     *govulncheckOSV     `json:"osv"`
+	Finding *govulncheckFinding `json:"finding"`
+}
+
+type govulncheckOSV struct {
+	ID               string `json:"id"`
+	Summary          string `json:"summary"`
+	Details          string `json:"details"`
+	DatabaseSpecific struct {
+		URL string `json:"url"`
+	} `json:"database_specific"`
+}
+
+type govulncheckFinding struct {
+	OSV          string `json:"osv"`
+	FixedVersion string `json:"fixed_version"`
+	Trace        []struct {
+		Module  string `json:"module"`
+		Version string `json:"version"`
+		Package string `json:"package"`
+	} `json:"trace"`
+}
+
+// ParseGovulncheck reads the stream of JSON messages written by
+// `govulncheck -json`.
+func ParseGovulncheck(contents []byte) (Report, error) {
+	osvs := map[string]govulncheckOSV{}
+	var findings []govulncheckFinding
+
+	decoder := json.NewDecoder(bytes.NewReader(contents))
+	for {
+		var message govulncheckMessage
+		err := decoder.Decode(&message)
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			return Report{}, fmt.Errorf("invalid govulncheck report: %s", err)
+		}
+
+		if message.OSV != nil {
+			osvs[message.OSV.ID] = *message.OSV
+		}
+
+		if message.Finding != nil && len(message.Finding.Trace) > 0 {
+			findings = append(findings, *message.Finding)
+		}
+	}
+
+	var vulnerabilities []vulnerability
+	for _, finding := range findings {
+		osv := osvs[finding.OSV]
+		frame := finding.Trace[0]
+
+		advisory := osv.DatabaseSpecific.URL
+		if advisory == "" {
+			advisory = "https://pkg.go.dev/vuln/" + finding.OSV
+		}
+
+		vulnerabilities = append(vulnerabilities, vulnerability{
+			ID:               finding.OSV,
+			Package:          frame.Module,
+			InstalledVersion: frame.Version,
+			FixedVersion:     finding.FixedVersion,
+			Summary:          osv.Summary,
+			Details:          osv.Details,
+			Advisory:         advisory,
+		})
+	}
+
+	return vulnerabilityReport(vulnerabilities), nil
+}
+
+type trivyReport struct {
+	Results []struct {
+		Target          string `json:"Target"`
+		Vulnerabilities []struct {
+			VulnerabilityID  string `json:"VulnerabilityID"`
+			PkgName          string `json:"PkgName"`
+			InstalledVersion string `json:"InstalledVersion"`
+			FixedVersion     string `json:"FixedVersion"`
+			Severity         string `json:"Severity"`
+			Title            string `json:"Title"`
+			Description      string `json:"Description"`
+			PrimaryURL       string `json:"PrimaryURL"`
+		} `json:"Vulnerabilities"`
+	} `json:"Results"`
+}
+
+// ParseTrivy reads a report written by `trivy --format json`.
+func ParseTrivy(contents []byte) (Report, error) {
+	var report trivyReport
+	if err := json.Unmarshal(contents, &report); err != nil {
+		return Report{}, fmt.Errorf("invalid trivy report: %s", err)
+	}
+
+	var vulnerabilities []vulnerability
+	for _, result := range report.Results {
+		for _, vuln := range result.Vulnerabilities {
+			vulnerabilities = append(vulnerabilities, vulnerability{
+				ID:               vuln.VulnerabilityID,
+				Package:          vuln.PkgName,
+				InstalledVersion: vuln.InstalledVersion,
+				FixedVersion:     vuln.FixedVersion,
+				Severity:         vuln.Severity,
+				Summary:          vuln.Title,
+				Details:          vuln.Description,
+				Advisory:         vuln.PrimaryURL,
+			})
+		}
+	}
+
+	return vulnerabilityReport(vulnerabilities), nil
+}
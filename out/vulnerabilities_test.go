@@ -0,0 +1,49 @@
+package out_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/cjcjameson/tracker-story-resource/out"
+)
+
+var _ = Describe("Parsing govulncheck reports", func() {
+	It("files one bug per vulnerability and module", func() {
+		report, err := out.ParseGovulncheck([]byte(Fixture("govulncheck.json")))
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(report.Findings).To(HaveLen(1))
+
+		finding := report.Findings[0]
+		Expect(finding.Name).To(Equal("GO-2024-2687 in golang.org/x/net"))
+		Expect(finding.Fingerprint).To(Equal("vuln:GO-2024-2687:golang.org/x/net"))
+		Expect(finding.Labels).To(Equal([]string{"vulnerability", "severity:unknown"}))
+		Expect(finding.Description).To(Equal(`HTTP/2 CONTINUATION flood in net/http
+
+* Affected: golang.org/x/net@v0.22.0
+* Fixed in: v0.23.0
+* Severity: unknown
+* Advisory: https://pkg.go.dev/vuln/GO-2024-2687
+
+An attacker may cause an HTTP/2 endpoint to read arbitrary amounts of header data.`))
+	})
+
+	It("rejects reports that are not JSON", func() {
+		_, err := out.ParseGovulncheck([]byte("Scanning your code..."))
+		Expect(err).To(MatchError(ContainSubstring("invalid govulncheck report")))
+	})
+})
+
+var _ = Describe("Parsing Trivy reports", func() {
+	It("files one bug per vulnerability and package", func() {
+		report, err := out.ParseTrivy([]byte(Fixture("trivy.json")))
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(report.Findings).To(HaveLen(2))
+		Expect(report.Findings[0].Name).To(Equal("CVE-2024-0727 in libcrypto3"))
+		Expect(report.Findings[0].Labels).To(Equal([]string{"vulnerability", "severity:medium"}))
+		Expect(report.Findings[0].Description).To(ContainSubstring("* Fixed in: 3.1.4-r5"))
+		Expect(report.Findings[0].Description).To(ContainSubstring("* Advisory: https://avd.aquasec.com/nvd/cve-2024-0727"))
+		Expect(report.Findings[1].Name).To(Equal("CVE-2024-0727 in libssl3"))
+	})
+})
@@ -0,0 +1,123 @@
+package resource_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/XenoPhex/go-tracker"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/cjcjameson/tracker-story-resource"
+	"github.com/cjcjameson/tracker-story-resource/trackerfake"
+)
+
+var _ = Describe("Recording Tracker interactions", func() {
+	var (
+		tmpdir   string
+		cassette string
+
+		retryDelay time.Duration
+	)
+
+	BeforeEach(func() {
+		var err error
+		tmpdir, err = ioutil.TempDir("", "cassettes")
+		Expect(err).NotTo(HaveOccurred())
+
+		cassette = filepath.Join(tmpdir, "tracker.json")
+
+		retryDelay = tracker.RetryDelay
+		tracker.RetryDelay = time.Millisecond
+	})
+
+	AfterEach(func() {
+		tracker.DefaultTransport = nil
+		tracker.RetryDelay = retryDelay
+
+		os.RemoveAll(tmpdir)
+	})
+
+	record := func(interact func(tracker.ProjectClient)) {
+		fake := trackerfake.New("secret-token", 1234)
+		defer fake.Close()
+
+		fake.AddMember(tracker.Person{Name: "Galen Erso", Email: "galen@empire.example.com"}, "owner")
+
+		recorder, err := tracker.NewRecorder(cassette, tracker.ModeRecord)
+		Expect(err).NotTo(HaveOccurred())
+		tracker.DefaultTransport = recorder
+
+		client, err := resource.NewProjectClient(fake.Source())
+		Expect(err).NotTo(HaveOccurred())
+
+		interact(client)
+	}
+
+	replay := func() (tracker.ProjectClient, *tracker.Recorder) {
+		recorder, err := tracker.NewRecorder(cassette, tracker.ModeReplay)
+		Expect(err).NotTo(HaveOccurred())
+		tracker.DefaultTransport = recorder
+
+		// nothing listens here; every response comes from the cassette
+		client, err := resource.NewProjectClient(resource.Source{
+			Token:      "another-token",
+			TrackerURL: "http://127.0.0.1:1",
+			ProjectID:  "1234",
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		return client, recorder
+	}
+
+	BeforeEach(func() {
+		record(func(client tracker.ProjectClient) {
+			story, err := client.CreateStory(tracker.Story{Name: "Cover the exhaust port", Type: tracker.StoryTypeBug})
+			Expect(err).NotTo(HaveOccurred())
+
+			err = client.DeliverStoryWithComment(story.ID, "delivered")
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = client.Memberships()
+			Expect(err).NotTo(HaveOccurred())
+		})
+	})
+
+	It("redacts tokens and email addresses", func() {
+		contents, err := ioutil.ReadFile(cassette)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(string(contents)).To(ContainSubstring("Cover the exhaust port"))
+		Expect(string(contents)).NotTo(ContainSubstring("secret-token"))
+		Expect(string(contents)).NotTo(ContainSubstring("galen@empire.example.com"))
+		Expect(string(contents)).To(ContainSubstring("redacted@example.com"))
+	})
+
+	It("replays the recorded interactions without a network", func() {
+		client, recorder := replay()
+
+		story, err := client.CreateStory(tracker.Story{Name: "Cover the exhaust port", Type: tracker.StoryTypeBug})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(story.State).To(BeEquivalentTo(tracker.StoryStateUnscheduled))
+
+		err = client.DeliverStoryWithComment(story.ID, "delivered")
+		Expect(err).NotTo(HaveOccurred())
+
+		memberships, err := client.Memberships()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(memberships[0].Person.Name).To(Equal("Galen Erso"))
+
+		Expect(recorder.Unplayed()).To(BeEmpty())
+	})
+
+	It("fails requests that were not recorded", func() {
+		client, recorder := replay()
+
+		_, err := client.CreateStory(tracker.Story{Name: "Add shielding"})
+		Expect(err).To(MatchError(ContainSubstring("no recorded interaction for POST /services/v5/projects/1234/stories")))
+
+		Expect(recorder.Unplayed()).To(HaveLen(4))
+	})
+})
@@ -0,0 +1,136 @@
+package trackerfake
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Fault changes how the fake answers matching requests, to simulate an
+// unreliable Tracker. A fault without a status, delay, truncation or hook
+// does nothing.
+type Fault struct {
+	// Method and Path select the requests affected. Path is a prefix of the
+	// request path below the project, e.g. /stories. Empty matches anything.
+	Method string
+	Path   string
+
+	// After lets that many matching requests through first. Times limits how
+	// many are affected after that; zero affects every one.
+	After int
+	Times int
+
+	// Status answers with an error instead of handling the request, e.g. 500
+	// or 401. RetryAfter is sent in seconds with it, for 429s.
+	Status     int
+	RetryAfter int
+
+	// Delay holds the response back, e.g. to exceed the client's timeout.
+	Delay time.Duration
+
+	// Truncate handles the request but sends only half of the response body.
+	Truncate bool
+
+	// Hook runs before the request is handled. It may seed or inspect the
+	// fake, e.g. to add stories between pages of a listing.
+	Hook func()
+
+	seen int
+}
+
+// Inject adds a fault. Faults are checked in the order they were added, and
+// only the first matching one applies to a request.
+func (s *Server) Inject(fault Fault) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.faults = append(s.faults, &fault)
+}
+
+// ClearFaults makes the fake reliable again.
+func (s *Server) ClearFaults() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.faults = nil
+}
+
+// fault finds the fault applying to the request, counting it against the
+// matching faults.
+func (s *Server) fault(r *http.Request) *Fault {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	prefix := "/services/v5/projects/" + strconv.Itoa(s.ProjectID)
+	path := strings.TrimPrefix(r.URL.Path, prefix)
+
+	for _, fault := range s.faults {
+		if fault.Method != "" && fault.Method != r.Method {
+			continue
+		}
+
+		if !strings.HasPrefix(path, fault.Path) {
+			continue
+		}
+
+		fault.seen++
+		if fault.seen <= fault.After {
+			continue
+		}
+
+		if fault.Times > 0 && fault.seen > fault.After+fault.Times {
+			continue
+		}
+
+		return fault
+	}
+
+	return nil
+}
+
+func (s *Server) serveWithFaults(w http.ResponseWriter, r *http.Request) {
+	fault := s.fault(r)
+	if fault == nil {
+		s.serve(w, r)
+		return
+	}
+
+	if fault.Hook != nil {
+		fault.Hook()
+	}
+
+	if fault.Delay > 0 {
+		time.Sleep(fault.Delay)
+	}
+
+	if fault.Status != 0 {
+		s.lock.Lock()
+		s.requests = append(s.requests, r.Method+" "+r.URL.RequestURI())
+		s.lock.Unlock()
+
+		if fault.RetryAfter > 0 {
+			w.Header().Set("Retry-After", strconv.Itoa(fault.RetryAfter))
+		}
+
+		writeError(w, fault.Status, "injected_fault", http.StatusText(fault.Status))
+		return
+	}
+
+	if !fault.Truncate {
+		s.serve(w, r)
+		return
+	}
+
+	recorder := httptest.NewRecorder()
+	s.serve(recorder, r)
+
+	for name, values := range recorder.Header() {
+		w.Header()[name] = values
+	}
+
+	body := recorder.Body.Bytes()
+	w.WriteHeader(recorder.Code)
+	w.Write(body[:len(body)/2])
+}
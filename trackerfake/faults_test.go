@@ -0,0 +1,161 @@
+package trackerfake_test
+
+import (
+	"context"
+	"time"
+
+	"github.com/XenoPhex/go-tracker"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/cjcjameson/tracker-story-resource"
+	"github.com/cjcjameson/tracker-story-resource/trackerfake"
+)
+
+var _ = Describe("Faults", func() {
+	var (
+		fake   *trackerfake.Server
+		client tracker.ProjectClient
+
+		retryDelay time.Duration
+		timeout    time.Duration
+	)
+
+	BeforeEach(func() {
+		retryDelay = tracker.RetryDelay
+		timeout = tracker.DefaultTimeout
+
+		tracker.RetryDelay = 10 * time.Millisecond
+		tracker.DefaultTimeout = 200 * time.Millisecond
+
+		fake = trackerfake.New("abc", 1234)
+
+		var err error
+		client, err = resource.NewProjectClient(fake.Source())
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		fake.Close()
+
+		tracker.RetryDelay = retryDelay
+		tracker.DefaultTimeout = timeout
+	})
+
+	It("retries reads through server errors", func() {
+		fake.AddStory(tracker.Story{Name: "vent"})
+		fake.Inject(trackerfake.Fault{Method: "GET", Path: "/stories", Status: 500, Times: 2})
+
+		stories, _, err := client.Stories(tracker.StoriesQuery{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(stories).To(HaveLen(1))
+		Expect(fake.Requests()).To(HaveLen(3))
+	})
+
+	It("gives up once the retries run out", func() {
+		fake.Inject(trackerfake.Fault{Method: "GET", Status: 503})
+
+		_, err := client.Story(1)
+		Expect(err).To(MatchError("request failed (503)"))
+		Expect(fake.Requests()).To(HaveLen(1 + tracker.MaxRetries))
+	})
+
+	It("does not repeat creates that failed, so they are not duplicated", func() {
+		fake.Inject(trackerfake.Fault{Method: "POST", Path: "/stories", Status: 500, Times: 1})
+
+		_, err := client.CreateStory(tracker.Story{Name: "vent"})
+		Expect(err).To(MatchError("request failed (500)"))
+		Expect(fake.Requests()).To(HaveLen(1))
+		Expect(fake.Stories()).To(BeEmpty())
+	})
+
+	It("waits as long as Tracker asks when rate limited", func() {
+		fake.Inject(trackerfake.Fault{Method: "POST", Status: 429, RetryAfter: 1, Times: 1})
+
+		start := time.Now()
+		_, err := client.CreateStory(tracker.Story{Name: "vent"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(time.Since(start)).To(BeNumerically(">=", time.Second))
+		Expect(fake.Stories()).To(HaveLen(1))
+	})
+
+	It("gives up on a hung request when its context is cancelled", func() {
+		fake.Inject(trackerfake.Fault{Delay: 500 * time.Millisecond, Times: 1})
+
+		ctx, cancel := context.WithCancel(context.Background())
+		time.AfterFunc(50*time.Millisecond, cancel)
+
+		start := time.Now()
+		_, _, err := client.WithContext(ctx).Stories(tracker.StoriesQuery{})
+		Expect(err).To(MatchError(ContainSubstring("context canceled")))
+		Expect(time.Since(start)).To(BeNumerically("<", 200*time.Millisecond))
+	})
+
+	It("retries reads that time out", func() {
+		fake.Inject(trackerfake.Fault{Delay: 300 * time.Millisecond, Times: 1})
+		fake.AddStory(tracker.Story{Name: "vent"})
+
+		stories, _, err := client.Stories(tracker.StoriesQuery{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(stories).To(HaveLen(1))
+	})
+
+	It("fails when every attempt times out", func() {
+		fake.Inject(trackerfake.Fault{Delay: 300 * time.Millisecond})
+
+		_, _, err := client.Stories(tracker.StoriesQuery{})
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(HavePrefix("failed to make request"))
+	})
+
+	It("reports truncated responses", func() {
+		fake.AddStory(tracker.Story{Name: "vent"})
+		fake.Inject(trackerfake.Fault{Truncate: true})
+
+		_, _, err := client.Stories(tracker.StoriesQuery{})
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(HavePrefix("invalid json response"))
+	})
+
+	It("reports a token revoked mid-run", func() {
+		story := fake.AddStory(tracker.Story{Name: "vent"})
+		fake.Inject(trackerfake.Fault{Status: 401, After: 1})
+
+		_, err := client.Story(story.ID)
+		Expect(err).NotTo(HaveOccurred())
+
+		_, err = client.Story(story.ID)
+		Expect(err).To(MatchError("invalid token"))
+	})
+
+	Context("when stories change between pages", func() {
+		BeforeEach(func() {
+			for i := 0; i < 4; i++ {
+				fake.AddStory(tracker.Story{Name: "unstarted"})
+			}
+		})
+
+		It("fetches stories added during the listing", func() {
+			fake.Inject(trackerfake.Fault{Method: "GET", After: 1, Times: 1, Hook: func() {
+				fake.AddStory(tracker.Story{Name: "added"})
+				fake.AddStory(tracker.Story{Name: "added"})
+			}})
+
+			stories, err := resource.AllStories(client, tracker.StoriesQuery{Limit: 2})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(stories).To(HaveLen(6))
+		})
+
+		It("stops when stories are removed during the listing", func() {
+			first := fake.Stories()[0]
+			fake.Inject(trackerfake.Fault{Method: "GET", After: 1, Times: 1, Hook: func() {
+				fake.RemoveStory(first.ID)
+			}})
+
+			stories, err := resource.AllStories(client, tracker.StoriesQuery{Limit: 2})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(stories).To(HaveLen(3))
+			Expect(fake.Requests()).To(HaveLen(2))
+		})
+	})
+})
@@ -0,0 +1,117 @@
+package trackerfake
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/XenoPhex/go-tracker"
+)
+
+// AddMember seeds a member of the project and returns the person with an ID.
+func (s *Server) AddMember(person tracker.Person, role string) tracker.Person {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if person.ID == 0 {
+		person.ID = s.nextID()
+	}
+
+	s.memberships = append(s.memberships, tracker.Membership{
+		ID:     s.nextID(),
+		Person: person,
+		Role:   role,
+	})
+
+	return person
+}
+
+// AddEpic seeds an epic, with its label, and returns it with its ID.
+func (s *Server) AddEpic(epic tracker.Epic) tracker.Epic {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return s.createEpic(epic)
+}
+
+func (s *Server) Epics() []tracker.Epic {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return append([]tracker.Epic{}, s.epics...)
+}
+
+func (s *Server) serveEpics(w http.ResponseWriter, r *http.Request, route []string) {
+	if len(route) == 0 {
+		switch r.Method {
+		case "GET":
+			writeJSON(w, http.StatusOK, append([]tracker.Epic{}, s.epics...))
+		case "POST":
+			var epic tracker.Epic
+			if decode(w, r, &epic) {
+				writeJSON(w, http.StatusOK, s.createEpic(epic))
+			}
+		default:
+			notFound(w)
+		}
+		return
+	}
+
+	id, ok := atoi(w, route[0])
+	if !ok {
+		return
+	}
+
+	for i, epic := range s.epics {
+		if epic.ID != id || len(route) != 1 {
+			continue
+		}
+
+		switch r.Method {
+		case "GET":
+			writeJSON(w, http.StatusOK, epic)
+		case "PUT":
+			if !decode(w, r, &epic) {
+				return
+			}
+
+			epic.ID = id
+			epic.UpdatedAt = s.now()
+			if epic.Label != nil {
+				label := s.label(epic.Label.Name)
+				epic.Label = &label
+			}
+			s.epics[i] = epic
+
+			writeJSON(w, http.StatusOK, epic)
+		case "DELETE":
+			s.epics = append(s.epics[:i], s.epics[i+1:]...)
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			notFound(w)
+		}
+		return
+	}
+
+	notFound(w)
+}
+
+// createEpic gives the epic a label named after it, as Tracker does, unless
+// one is given.
+func (s *Server) createEpic(epic tracker.Epic) tracker.Epic {
+	epic.ID = s.nextID()
+	epic.ProjectID = s.ProjectID
+	epic.URL = fmt.Sprintf("https://www.pivotaltracker.com/epic/show/%d", epic.ID)
+	epic.CreatedAt = s.now()
+	epic.UpdatedAt = epic.CreatedAt
+
+	name := epic.Name
+	if epic.Label != nil && epic.Label.Name != "" {
+		name = epic.Label.Name
+	}
+
+	label := s.label(name)
+	epic.Label = &label
+
+	s.epics = append(s.epics, epic)
+	return epic
+}
@@ -0,0 +1,219 @@
+// Package trackerfake is an in-memory Pivotal Tracker for tests. It serves
+// the parts of the v5 API that the resource uses, keeps what it is sent, and
+// lets tests seed and inspect its state directly.
+package trackerfake
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/XenoPhex/go-tracker"
+	"github.com/cjcjameson/tracker-story-resource"
+)
+
+type Server struct {
+	Token     string
+	ProjectID int
+
+	// Now is the clock used for timestamps. It defaults to time.Now.
+	Now func() time.Time
+
+	// PointScale and BugsAndChoresAreEstimatable are the project's estimate
+	// settings. The point scale defaults to Tracker's linear one.
+	PointScale                  string
+	BugsAndChoresAreEstimatable bool
+
+	// AutomaticPlanning is on by default, as it is in Tracker.
+	AutomaticPlanning bool
+
+	server *httptest.Server
+
+	lock        sync.Mutex
+	lastID      int
+	version     int
+	stories     []tracker.Story
+	comments    map[int][]tracker.Comment
+	blockers    map[int][]tracker.Blocker
+	activity    map[int][]tracker.Activity
+	labels      map[string]tracker.Label
+	epics       []tracker.Epic
+	memberships []tracker.Membership
+	requests    []string
+	faults      []*Fault
+}
+
+// New starts a fake Tracker that accepts the token for the project.
+func New(token string, projectID int) *Server {
+	s := &Server{
+		Token:             token,
+		ProjectID:         projectID,
+		Now:               time.Now,
+		PointScale:        "0,1,2,3",
+		AutomaticPlanning: true,
+		comments:          map[int][]tracker.Comment{},
+		blockers:          map[int][]tracker.Blocker{},
+		activity:          map[int][]tracker.Activity{},
+		labels:            map[string]tracker.Label{},
+	}
+
+	s.server = httptest.NewServer(http.HandlerFunc(s.serveWithFaults))
+	return s
+}
+
+func (s *Server) URL() string {
+	return s.server.URL
+}
+
+func (s *Server) Close() {
+	s.server.Close()
+}
+
+// Source is the resource configuration pointing at the fake.
+func (s *Server) Source() resource.Source {
+	return resource.Source{
+		Token:      s.Token,
+		TrackerURL: s.URL(),
+		ProjectID:  strconv.Itoa(s.ProjectID),
+	}
+}
+
+// Env runs check, in and out against the fake, logging to log with the
+// clock stopped at now.
+func (s *Server) Env(log io.Writer, now time.Time) resource.Env {
+	client, err := resource.NewProjectClient(s.Source())
+	if err != nil {
+		panic(err)
+	}
+
+	return resource.Env{
+		Client: client,
+		Log:    log,
+		Now:    func() time.Time { return now },
+	}
+}
+
+// Requests lists the method and path, with query, of every request received.
+func (s *Server) Requests() []string {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return append([]string{}, s.requests...)
+}
+
+func (s *Server) serve(w http.ResponseWriter, r *http.Request) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.requests = append(s.requests, r.Method+" "+r.URL.RequestURI())
+
+	if r.Header.Get("X-TrackerToken") != s.Token {
+		writeError(w, http.StatusForbidden, "invalid_authentication", "Invalid authentication credentials were presented.")
+		return
+	}
+
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if len(parts) < 4 || parts[0] != "services" || parts[1] != "v5" || parts[2] != "projects" {
+		writeError(w, http.StatusNotFound, "route_not_found", "The path you requested has no valid endpoint.")
+		return
+	}
+
+	if parts[3] != strconv.Itoa(s.ProjectID) {
+		writeError(w, http.StatusNotFound, "unfound_resource", "The object you tried to access could not be found.")
+		return
+	}
+
+	route := parts[4:]
+	switch {
+	case len(route) == 0 && r.Method == "GET":
+		writeJSON(w, http.StatusOK, tracker.Project{
+			Id:                          s.ProjectID,
+			PointScale:                  s.PointScale,
+			BugsAndChoresAreEstimatable: s.BugsAndChoresAreEstimatable,
+			AutomaticPlanning:           s.AutomaticPlanning,
+		})
+	case len(route) >= 1 && route[0] == "stories":
+		s.serveStories(w, r, route[1:])
+	case len(route) >= 1 && route[0] == "epics":
+		s.serveEpics(w, r, route[1:])
+	case len(route) == 1 && route[0] == "memberships" && r.Method == "GET":
+		writeJSON(w, http.StatusOK, append([]tracker.Membership{}, s.memberships...))
+	default:
+		writeError(w, http.StatusNotFound, "route_not_found", "The path you requested has no valid endpoint.")
+	}
+}
+
+func (s *Server) nextID() int {
+	s.lastID++
+	return s.lastID
+}
+
+func (s *Server) now() *time.Time {
+	now := s.Now().UTC()
+	return &now
+}
+
+// label finds the project label with the name, creating it if needed.
+func (s *Server) label(name string) tracker.Label {
+	label, found := s.labels[name]
+	if !found {
+		label = tracker.Label{ID: s.nextID(), ProjectID: s.ProjectID, Name: name}
+		s.labels[name] = label
+	}
+
+	return label
+}
+
+func (s *Server) record(storyID int, kind string, message string) {
+	s.version++
+	s.activity[storyID] = append(s.activity[storyID], tracker.Activity{
+		Kind:           kind,
+		GUID:           fmt.Sprintf("%d_%d", s.ProjectID, s.version),
+		ProjectVersion: s.version,
+		Message:        message,
+		OccurredAt:     *s.now(),
+	})
+}
+
+func decode(w http.ResponseWriter, r *http.Request, object interface{}) bool {
+	if err := json.NewDecoder(r.Body).Decode(object); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_parameter", err.Error())
+		return false
+	}
+
+	return true
+}
+
+func writeJSON(w http.ResponseWriter, status int, object interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(object)
+}
+
+func writeError(w http.ResponseWriter, status int, code string, message string) {
+	writeJSON(w, status, map[string]string{
+		"kind":  "error",
+		"code":  code,
+		"error": message,
+	})
+}
+
+func notFound(w http.ResponseWriter) {
+	writeError(w, http.StatusNotFound, "unfound_resource", "The object you tried to access could not be found.")
+}
+
+func atoi(w http.ResponseWriter, value string) (int, bool) {
+	id, err := strconv.Atoi(value)
+	if err != nil {
+		notFound(w)
+		return 0, false
+	}
+
+	return id, true
+}
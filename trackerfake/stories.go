@@ -0,0 +1,658 @@
+package trackerfake
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/XenoPhex/go-tracker"
+	"github.com/cjcjameson/tracker-story-resource"
+)
+
+const defaultLimit = 100
+
+// AddStory seeds a story as if it had been created through the API, and
+// returns it with its ID.
+func (s *Server) AddStory(story tracker.Story) tracker.Story {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return s.createStory(story)
+}
+
+// RemoveStory deletes a story as if someone else had deleted it.
+func (s *Server) RemoveStory(id int) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if index := s.storyIndex(id); index != -1 {
+		s.stories = append(s.stories[:index], s.stories[index+1:]...)
+	}
+}
+
+// Stories returns every story in priority order.
+func (s *Server) Stories() []tracker.Story {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return append([]tracker.Story{}, s.stories...)
+}
+
+func (s *Server) Story(id int) (tracker.Story, bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	index := s.storyIndex(id)
+	if index == -1 {
+		return tracker.Story{}, false
+	}
+
+	return s.stories[index], true
+}
+
+func (s *Server) Comments(storyID int) []tracker.Comment {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return append([]tracker.Comment{}, s.comments[storyID]...)
+}
+
+func (s *Server) Blockers(storyID int) []tracker.Blocker {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return append([]tracker.Blocker{}, s.blockers[storyID]...)
+}
+
+func (s *Server) serveStories(w http.ResponseWriter, r *http.Request, route []string) {
+	if len(route) == 0 {
+		switch r.Method {
+		case "GET":
+			s.listStories(w, r.URL.Query())
+		case "POST":
+			var story tracker.Story
+			if !decode(w, r, &story) {
+				return
+			}
+
+			if err := s.validate(story); err != nil {
+				writeError(w, http.StatusBadRequest, "invalid_parameter", err.Error())
+				return
+			}
+
+			writeJSON(w, http.StatusOK, s.createStory(story))
+		default:
+			notFound(w)
+		}
+		return
+	}
+
+	id, ok := atoi(w, route[0])
+	if !ok {
+		return
+	}
+
+	index := s.storyIndex(id)
+	if index == -1 {
+		notFound(w)
+		return
+	}
+
+	switch {
+	case len(route) == 1 && r.Method == "GET":
+		writeJSON(w, http.StatusOK, s.stories[index])
+	case len(route) == 1 && r.Method == "PUT":
+		s.updateStory(w, r, index)
+	case len(route) == 1 && r.Method == "DELETE":
+		s.stories = append(s.stories[:index], s.stories[index+1:]...)
+		s.record(id, "story_delete_activity", "deleted this story")
+		w.WriteHeader(http.StatusNoContent)
+	case len(route) == 2 && route[1] == "comments":
+		s.serveComments(w, r, id)
+	case len(route) == 2 && route[1] == "labels":
+		s.serveLabels(w, r, index)
+	case len(route) >= 2 && route[1] == "blockers":
+		s.serveBlockers(w, r, id, route[2:])
+	case len(route) >= 2 && route[1] == "tasks":
+		s.serveTasks(w, r, index, route[2:])
+	case len(route) == 2 && route[1] == "activity" && r.Method == "GET":
+		s.listActivity(w, r.URL.Query(), id)
+	default:
+		notFound(w)
+	}
+}
+
+func (s *Server) listStories(w http.ResponseWriter, params url.Values) {
+	limit, offset, err := page(params)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_parameter", err.Error())
+		return
+	}
+
+	matches, err := s.matcher(params)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_parameter", err.Error())
+		return
+	}
+
+	var found []tracker.Story
+	for _, story := range s.stories {
+		if matches(story) {
+			found = append(found, story)
+		}
+	}
+
+	total := len(found)
+	if offset > total {
+		offset = total
+	}
+
+	end := offset + limit
+	if end > total {
+		end = total
+	}
+
+	returned := append([]tracker.Story{}, found[offset:end]...)
+
+	for _, field := range strings.Split(params.Get("fields"), ",") {
+		for i := range returned {
+			switch field {
+			case "comments":
+				returned[i].Comments = append([]tracker.Comment{}, s.comments[returned[i].ID]...)
+			case "blockers":
+				returned[i].Blockers = append([]tracker.Blocker{}, s.blockers[returned[i].ID]...)
+			}
+		}
+	}
+
+	w.Header().Set("X-Tracker-Pagination-Total", strconv.Itoa(total))
+	w.Header().Set("X-Tracker-Pagination-Offset", strconv.Itoa(offset))
+	w.Header().Set("X-Tracker-Pagination-Limit", strconv.Itoa(limit))
+	w.Header().Set("X-Tracker-Pagination-Returned", strconv.Itoa(len(returned)))
+	writeJSON(w, http.StatusOK, returned)
+}
+
+func page(params url.Values) (int, int, error) {
+	limit := defaultLimit
+	if value := params.Get("limit"); value != "" {
+		var err error
+		limit, err = strconv.Atoi(value)
+		if err != nil {
+			return 0, 0, fmt.Errorf("invalid limit: %s", value)
+		}
+	}
+
+	offset := 0
+	if value := params.Get("offset"); value != "" {
+		var err error
+		offset, err = strconv.Atoi(value)
+		if err != nil {
+			return 0, 0, fmt.Errorf("invalid offset: %s", value)
+		}
+	}
+
+	return limit, offset, nil
+}
+
+// matcher supports the story query parameters the resource sends, and the
+// state, label and type terms of Tracker's search filter.
+func (s *Server) matcher(params url.Values) (func(tracker.Story) bool, error) {
+	var checks []func(tracker.Story) bool
+
+	if state := params.Get("with_state"); state != "" {
+		checks = append(checks, func(story tracker.Story) bool {
+			return string(story.State) == state
+		})
+	}
+
+	if label := params.Get("with_label"); label != "" {
+		checks = append(checks, func(story tracker.Story) bool {
+			return resource.HasLabel(story, label)
+		})
+	}
+
+	for _, bound := range []string{"accepted_after", "accepted_before"} {
+		value := params.Get(bound)
+		if value == "" {
+			continue
+		}
+
+		at, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %s", bound, value)
+		}
+
+		after := bound == "accepted_after"
+		checks = append(checks, func(story tracker.Story) bool {
+			if story.AcceptedAt == nil {
+				return false
+			}
+
+			if after {
+				return story.AcceptedAt.After(at)
+			}
+
+			return story.AcceptedAt.Before(at)
+		})
+	}
+
+	for _, term := range strings.Fields(params.Get("filter")) {
+		check, err := filterTerm(term)
+		if err != nil {
+			return nil, err
+		}
+
+		checks = append(checks, check)
+	}
+
+	return func(story tracker.Story) bool {
+		for _, check := range checks {
+			if !check(story) {
+				return false
+			}
+		}
+
+		return true
+	}, nil
+}
+
+func filterTerm(term string) (func(tracker.Story) bool, error) {
+	negated := strings.HasPrefix(term, "-")
+	fields := strings.SplitN(strings.TrimPrefix(term, "-"), ":", 2)
+	if len(fields) != 2 {
+		return nil, fmt.Errorf("unsupported filter: %s", term)
+	}
+
+	values := strings.Split(strings.Trim(fields[1], `"`), ",")
+
+	var field func(tracker.Story) []string
+	switch fields[0] {
+	case "state":
+		field = func(story tracker.Story) []string { return []string{string(story.State)} }
+	case "type":
+		field = func(story tracker.Story) []string { return []string{string(story.Type)} }
+	case "label":
+		field = func(story tracker.Story) []string {
+			var names []string
+			for _, label := range story.Labels {
+				names = append(names, label.Name)
+			}
+			return names
+		}
+	default:
+		return nil, fmt.Errorf("unsupported filter: %s", term)
+	}
+
+	return func(story tracker.Story) bool {
+		for _, have := range field(story) {
+			for _, want := range values {
+				if have == want {
+					return !negated
+				}
+			}
+		}
+
+		return negated
+	}, nil
+}
+
+func (s *Server) createStory(story tracker.Story) tracker.Story {
+	story.ID = s.nextID()
+	story.ProjectID = s.ProjectID
+	story.URL = fmt.Sprintf("https://www.pivotaltracker.com/story/show/%d", story.ID)
+	story.CreatedAt = s.now()
+	story.UpdatedAt = story.CreatedAt
+
+	if story.Type == "" {
+		story.Type = tracker.StoryTypeFeature
+	}
+
+	if story.State == "" {
+		story.State = tracker.StoryStateUnscheduled
+	}
+
+	if story.State == tracker.StoryStateAccepted && story.AcceptedAt == nil {
+		story.AcceptedAt = story.CreatedAt
+	}
+
+	story.Labels = s.normalizeLabels(story.Labels)
+
+	// comments and blockers are kept apart, and only listed when asked for
+	story.Comments = nil
+	story.Blockers = nil
+
+	for i := range story.Tasks {
+		story.Tasks[i].ID = s.nextID()
+		story.Tasks[i].StoryID = story.ID
+		story.Tasks[i].Position = i + 1
+		story.Tasks[i].CreatedAt = story.CreatedAt
+	}
+
+	s.insert(story)
+	s.record(story.ID, "story_create_activity", fmt.Sprintf("added this %s", story.Type))
+
+	return s.stories[s.storyIndex(story.ID)]
+}
+
+// validate rejects the stories Tracker does: only projects without automatic
+// planning take planned stories, features past unstarted must be estimated,
+// and estimates must be on the point scale of a type the project estimates.
+func (s *Server) validate(story tracker.Story) error {
+	if story.State == tracker.StoryStatePlanned && s.AutomaticPlanning {
+		return errors.New("Stories can only be planned in projects without automatic planning.")
+	}
+
+	feature := story.Type == "" || story.Type == tracker.StoryTypeFeature
+
+	if story.Estimate == nil {
+		if feature && estimated(story.State) {
+			return fmt.Errorf("Stories in the %s state must be estimated.", story.State)
+		}
+
+		return nil
+	}
+
+	if !feature && !s.BugsAndChoresAreEstimatable {
+		return errors.New("Only features can be estimated in this project.")
+	}
+
+	for _, point := range strings.Split(s.PointScale, ",") {
+		if value, err := strconv.ParseFloat(point, 64); err == nil && value == *story.Estimate {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("Estimate %g is not on the project's point scale.", *story.Estimate)
+}
+
+// estimated is whether features in the state must have an estimate.
+func estimated(state tracker.StoryState) bool {
+	switch state {
+	case tracker.StoryStateStarted, tracker.StoryStateFinished, tracker.StoryStateDelivered,
+		tracker.StoryStateAccepted, tracker.StoryStateRejected:
+		return true
+	}
+
+	return false
+}
+
+func sameEstimate(a, b *float64) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+
+	return *a == *b
+}
+
+func (s *Server) updateStory(w http.ResponseWriter, r *http.Request, index int) {
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_parameter", err.Error())
+		return
+	}
+
+	// only the fields present in the request change
+	story := s.stories[index]
+	previousState := story.State
+	if err := json.Unmarshal(body, &story); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_parameter", err.Error())
+		return
+	}
+
+	// stories seeded past unstarted without an estimate are left be: only
+	// updates that start a story or change its estimate are checked
+	previous := s.stories[index]
+	if (story.State != previousState && (estimated(story.State) && !estimated(previousState) || story.State == tracker.StoryStatePlanned)) ||
+		!sameEstimate(story.Estimate, previous.Estimate) {
+		if err := s.validate(story); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid_parameter", err.Error())
+			return
+		}
+	}
+
+	story.ID = s.stories[index].ID
+	story.Labels = s.normalizeLabels(story.Labels)
+	story.UpdatedAt = s.now()
+
+	if story.State == tracker.StoryStateAccepted && previousState != tracker.StoryStateAccepted {
+		story.AcceptedAt = story.UpdatedAt
+	}
+
+	s.stories = append(s.stories[:index], s.stories[index+1:]...)
+	s.insert(story)
+
+	message := "edited this " + string(story.Type)
+	if story.State != previousState {
+		message = string(story.State) + " this " + string(story.Type)
+	}
+	s.record(story.ID, "story_update_activity", message)
+
+	writeJSON(w, http.StatusOK, s.stories[s.storyIndex(story.ID)])
+}
+
+// insert places the story next to its before or after neighbour, or at the
+// end when it has none.
+func (s *Server) insert(story tracker.Story) {
+	at := len(s.stories)
+	if index := s.storyIndex(story.BeforeID); story.BeforeID != 0 && index != -1 {
+		at = index
+	} else if index := s.storyIndex(story.AfterID); story.AfterID != 0 && index != -1 {
+		at = index + 1
+	}
+
+	story.BeforeID = 0
+	story.AfterID = 0
+
+	s.stories = append(s.stories, tracker.Story{})
+	copy(s.stories[at+1:], s.stories[at:])
+	s.stories[at] = story
+}
+
+func (s *Server) storyIndex(id int) int {
+	for i, story := range s.stories {
+		if story.ID == id {
+			return i
+		}
+	}
+
+	return -1
+}
+
+func (s *Server) normalizeLabels(labels []tracker.Label) []tracker.Label {
+	var normalized []tracker.Label
+	for _, label := range labels {
+		normalized = append(normalized, s.label(label.Name))
+	}
+
+	return normalized
+}
+
+func (s *Server) serveComments(w http.ResponseWriter, r *http.Request, storyID int) {
+	switch r.Method {
+	case "GET":
+		writeJSON(w, http.StatusOK, append([]tracker.Comment{}, s.comments[storyID]...))
+	case "POST":
+		var comment tracker.Comment
+		if !decode(w, r, &comment) {
+			return
+		}
+
+		comment.ID = s.nextID()
+		comment.StoryID = storyID
+		comment.CreatedAt = s.now()
+
+		s.comments[storyID] = append(s.comments[storyID], comment)
+		s.record(storyID, "comment_create_activity", "added comment: \""+comment.Text+"\"")
+
+		writeJSON(w, http.StatusOK, comment)
+	default:
+		notFound(w)
+	}
+}
+
+func (s *Server) serveLabels(w http.ResponseWriter, r *http.Request, index int) {
+	switch r.Method {
+	case "GET":
+		writeJSON(w, http.StatusOK, append([]tracker.Label{}, s.stories[index].Labels...))
+	case "POST":
+		var label tracker.Label
+		if !decode(w, r, &label) {
+			return
+		}
+
+		label = s.label(label.Name)
+		if !resource.HasLabel(s.stories[index], label.Name) {
+			s.stories[index].Labels = append(s.stories[index].Labels, label)
+			s.record(s.stories[index].ID, "label_create_activity", "added label \""+label.Name+"\"")
+		}
+
+		writeJSON(w, http.StatusOK, label)
+	default:
+		notFound(w)
+	}
+}
+
+func (s *Server) serveBlockers(w http.ResponseWriter, r *http.Request, storyID int, route []string) {
+	if len(route) == 0 {
+		switch r.Method {
+		case "GET":
+			writeJSON(w, http.StatusOK, append([]tracker.Blocker{}, s.blockers[storyID]...))
+		case "POST":
+			var blocker tracker.Blocker
+			if !decode(w, r, &blocker) {
+				return
+			}
+
+			blocker.ID = s.nextID()
+			blocker.StoryID = storyID
+			blocker.CreatedAt = s.now()
+			blocker.UpdatedAt = blocker.CreatedAt
+
+			s.blockers[storyID] = append(s.blockers[storyID], blocker)
+			s.record(storyID, "blocker_create_activity", "added blocker \""+blocker.Description+"\"")
+
+			writeJSON(w, http.StatusOK, blocker)
+		default:
+			notFound(w)
+		}
+		return
+	}
+
+	id, ok := atoi(w, route[0])
+	if !ok {
+		return
+	}
+
+	blockers := s.blockers[storyID]
+	for i, blocker := range blockers {
+		if blocker.ID != id {
+			continue
+		}
+
+		switch r.Method {
+		case "PUT":
+			if !decode(w, r, &blocker) {
+				return
+			}
+
+			blocker.ID = id
+			blocker.StoryID = storyID
+			blocker.UpdatedAt = s.now()
+			blockers[i] = blocker
+
+			writeJSON(w, http.StatusOK, blocker)
+		case "DELETE":
+			s.blockers[storyID] = append(blockers[:i], blockers[i+1:]...)
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			notFound(w)
+		}
+		return
+	}
+
+	notFound(w)
+}
+
+func (s *Server) serveTasks(w http.ResponseWriter, r *http.Request, index int, route []string) {
+	story := &s.stories[index]
+
+	if len(route) == 0 {
+		switch r.Method {
+		case "GET":
+			writeJSON(w, http.StatusOK, append([]tracker.Task{}, story.Tasks...))
+		case "POST":
+			var task tracker.Task
+			if !decode(w, r, &task) {
+				return
+			}
+
+			task.ID = s.nextID()
+			task.StoryID = story.ID
+			task.Position = len(story.Tasks) + 1
+			task.CreatedAt = s.now()
+			task.UpdatedAt = task.CreatedAt
+
+			story.Tasks = append(story.Tasks, task)
+			s.record(story.ID, "task_create_activity", "added task \""+task.Description+"\"")
+
+			writeJSON(w, http.StatusOK, task)
+		default:
+			notFound(w)
+		}
+		return
+	}
+
+	id, ok := atoi(w, route[0])
+	if !ok {
+		return
+	}
+
+	for i, task := range story.Tasks {
+		if task.ID != id || r.Method != "DELETE" {
+			continue
+		}
+
+		story.Tasks = append(story.Tasks[:i], story.Tasks[i+1:]...)
+		for j := range story.Tasks {
+			story.Tasks[j].Position = j + 1
+		}
+		s.record(story.ID, "task_delete_activity", "deleted task \""+task.Description+"\"")
+
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	notFound(w)
+}
+
+func (s *Server) listActivity(w http.ResponseWriter, params url.Values, storyID int) {
+	limit, offset, err := page(params)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_parameter", err.Error())
+		return
+	}
+
+	// Tracker lists the most recent activity first
+	all := s.activity[storyID]
+	activities := []tracker.Activity{}
+	for i := len(all) - 1; i >= 0; i-- {
+		activities = append(activities, all[i])
+	}
+
+	if offset > len(activities) {
+		offset = len(activities)
+	}
+
+	end := offset + limit
+	if end > len(activities) {
+		end = len(activities)
+	}
+
+	writeJSON(w, http.StatusOK, activities[offset:end])
+}
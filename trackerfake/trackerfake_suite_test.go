@@ -0,0 +1,13 @@
+package trackerfake_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestTrackerfake(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Trackerfake Suite")
+}
@@ -0,0 +1,158 @@
+package trackerfake_test
+
+import (
+	"time"
+
+	"github.com/XenoPhex/go-tracker"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/cjcjameson/tracker-story-resource"
+	"github.com/cjcjameson/tracker-story-resource/trackerfake"
+)
+
+var _ = Describe("Fake Tracker", func() {
+	var (
+		fake   *trackerfake.Server
+		client tracker.ProjectClient
+		now    time.Time
+	)
+
+	BeforeEach(func() {
+		fake = trackerfake.New("abc", 1234)
+
+		now = time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+		fake.Now = func() time.Time { return now }
+
+		var err error
+		client, err = resource.NewProjectClient(fake.Source())
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		fake.Close()
+	})
+
+	It("keeps stories through create, deliver and comment", func() {
+		story, err := client.CreateStory(tracker.Story{
+			Name:   "cover the exhaust port",
+			Type:   tracker.StoryTypeBug,
+			State:  tracker.StoryStateFinished,
+			Labels: []tracker.Label{{Name: "reactor"}},
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(story.ID).NotTo(BeZero())
+		Expect(*story.CreatedAt).To(Equal(now))
+
+		err = client.DeliverStoryWithComment(story.ID, "delivered in build 42")
+		Expect(err).NotTo(HaveOccurred())
+
+		delivered, found := fake.Story(story.ID)
+		Expect(found).To(BeTrue())
+		Expect(delivered.State).To(BeEquivalentTo(tracker.StoryStateDelivered))
+		Expect(delivered.Name).To(Equal("cover the exhaust port"))
+		Expect(delivered.Labels).To(HaveLen(1))
+
+		comments := fake.Comments(story.ID)
+		Expect(comments).To(HaveLen(1))
+		Expect(comments[0].Text).To(Equal("delivered in build 42"))
+
+		activities, err := client.StoryActivity(story.ID, tracker.ActivityQuery{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(activities).To(HaveLen(3))
+		Expect(activities[0].Kind).To(Equal("comment_create_activity"))
+		Expect(activities[1].Message).To(Equal("delivered this bug"))
+	})
+
+	It("stamps stories when they are accepted", func() {
+		story := fake.AddStory(tracker.Story{Name: "vent", State: tracker.StoryStateDelivered})
+
+		now = now.Add(time.Hour)
+		_, err := client.UpdateStory(tracker.Story{ID: story.ID, State: tracker.StoryStateAccepted})
+		Expect(err).NotTo(HaveOccurred())
+
+		accepted, _, err := client.Stories(tracker.StoriesQuery{AcceptedAfter: now.Add(-time.Minute)})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(accepted).To(HaveLen(1))
+		Expect(*accepted[0].AcceptedAt).To(Equal(now))
+	})
+
+	It("paginates and filters stories", func() {
+		for i := 0; i < 5; i++ {
+			fake.AddStory(tracker.Story{Name: "unstarted", State: tracker.StoryStateUnstarted})
+		}
+		fake.AddStory(tracker.Story{Name: "accepted", State: tracker.StoryStateAccepted})
+
+		stories, pagination, err := client.Stories(tracker.StoriesQuery{
+			Filter: "-state:accepted",
+			Limit:  2,
+			Offset: 4,
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(stories).To(HaveLen(1))
+		Expect(pagination.Total).To(Equal(5))
+		Expect(pagination.Returned).To(Equal(1))
+
+		all, err := resource.AllStories(client, tracker.StoriesQuery{State: tracker.StoryStateUnstarted, Limit: 2})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(all).To(HaveLen(5))
+	})
+
+	It("places stories next to their neighbours", func() {
+		first := fake.AddStory(tracker.Story{Name: "first"})
+		fake.AddStory(tracker.Story{Name: "last"})
+
+		_, err := client.CreateStory(tracker.Story{Name: "middle", AfterID: first.ID})
+		Expect(err).NotTo(HaveOccurred())
+		_, err = client.CreateStory(tracker.Story{Name: "top", BeforeID: first.ID})
+		Expect(err).NotTo(HaveOccurred())
+
+		var names []string
+		for _, story := range fake.Stories() {
+			names = append(names, story.Name)
+		}
+		Expect(names).To(Equal([]string{"top", "first", "middle", "last"}))
+	})
+
+	It("labels stories once", func() {
+		story := fake.AddStory(tracker.Story{Name: "vent"})
+
+		for i := 0; i < 2; i++ {
+			_, err := client.AddStoryLabel(story.ID, tracker.Label{Name: "v1.0.0"})
+			Expect(err).NotTo(HaveOccurred())
+		}
+
+		labeled, _ := fake.Story(story.ID)
+		Expect(labeled.Labels).To(HaveLen(1))
+	})
+
+	It("serves epics and members", func() {
+		fake.AddMember(tracker.Person{Name: "Galen Erso"}, "owner")
+
+		people, err := resource.PeopleByID(client)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(people).To(HaveLen(1))
+
+		epic, err := client.CreateEpic(tracker.Epic{Name: "Stardust"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(epic.Label.Name).To(Equal("Stardust"))
+		Expect(fake.Epics()).To(HaveLen(1))
+	})
+
+	It("rejects requests with the wrong token", func() {
+		client := tracker.NewClientWithURL("wrong", fake.URL()).InProject(1234)
+
+		_, _, err := client.Stories(tracker.StoriesQuery{})
+		Expect(err).To(MatchError("request failed (403)"))
+	})
+
+	It("returns not found for unknown stories", func() {
+		_, err := client.Story(99)
+		Expect(err).To(MatchError("request failed (404)"))
+	})
+
+	It("records the requests it receives", func() {
+		client.Stories(tracker.StoriesQuery{State: tracker.StoryStateDelivered})
+		Expect(fake.Requests()).To(Equal([]string{"GET /services/v5/projects/1234/stories?with_state=delivered"}))
+	})
+})